@@ -0,0 +1,98 @@
+package browser
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ghtui/ghtui/config"
+)
+
+// Command returns the command used to open URLs: the configured one, then
+// $BROWSER, then the platform's default opener.
+func Command(configured string) []string {
+	if args := strings.Fields(configured); len(args) > 0 {
+		return args
+	}
+	if args := strings.Fields(os.Getenv("BROWSER")); len(args) > 0 {
+		return args
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open"}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler"}
+	default:
+		return []string{"xdg-open"}
+	}
+}
+
+// Open opens url with the command configured, appending the URL to its
+// arguments.
+func Open(configured string, url string) error {
+	args := Command(configured)
+	if len(args) == 0 {
+		return errors.New("no command to open URLs with")
+	}
+	cmd := exec.Command(args[0], append(args[1:], url)...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Don't leave the opener as a zombie process.
+	go cmd.Wait()
+	return nil
+}
+
+// Copy puts text on the system clipboard.
+func Copy(text string) error {
+	return clipboard.WriteAll(text)
+}
+
+// OpenedMsg reports how opening a URL with OpenCmd went.
+type OpenedMsg struct {
+	URL string
+	Err error
+}
+
+// Status describes the outcome for the status bar.
+func (msg OpenedMsg) Status() string {
+	if msg.Err != nil {
+		return "Could not open " + msg.URL + ": " + msg.Err.Error()
+	}
+	return "Opened " + msg.URL
+}
+
+// OpenCmd opens url with the command configured in cfg and reports the
+// outcome with an OpenedMsg.
+func OpenCmd(cfg *config.Config, url string) tea.Cmd {
+	return func() tea.Msg {
+		return OpenedMsg{URL: url, Err: Open(cfg.Browser, url)}
+	}
+}
+
+// CopiedMsg reports how copying a URL with CopyCmd went.
+type CopiedMsg struct {
+	URL string
+	Err error
+}
+
+// Status describes the outcome for the status bar.
+func (msg CopiedMsg) Status() string {
+	if msg.Err != nil {
+		return "Could not copy " + msg.URL + ": " + msg.Err.Error()
+	}
+	return "Copied " + msg.URL
+}
+
+// CopyCmd copies url to the clipboard and reports the outcome with a
+// CopiedMsg.
+func CopyCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		return CopiedMsg{URL: url, Err: Copy(url)}
+	}
+}
@@ -0,0 +1,80 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ghtui/ghtui/config"
+)
+
+// stubOpener writes a script that records its arguments to a file, which it
+// returns along with the script.
+func stubOpener(t *testing.T) (script string, out string) {
+	t.Helper()
+	dir := t.TempDir()
+	script = filepath.Join(dir, "opener")
+	out = filepath.Join(dir, "args")
+	body := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + out + ".tmp && mv " + out + ".tmp " + out + "\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return script, out
+}
+
+// waitForArgs reads the arguments the stub opener was started with.
+func waitForArgs(t *testing.T, out string) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if b, err := os.ReadFile(out); err == nil {
+			return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the opener was never started")
+	return nil
+}
+
+func TestOpenPassesURL(t *testing.T) {
+	script, out := stubOpener(t)
+	url := "https://github.com/owner/repo/blob/main/a%20b.go#L3-L9"
+
+	if err := Open(script+" --new-tab", url); err != nil {
+		t.Fatal(err)
+	}
+	if args := waitForArgs(t, out); !reflect.DeepEqual(args, []string{"--new-tab", url}) {
+		t.Errorf("the opener got %q, want --new-tab and the URL", args)
+	}
+}
+
+func TestOpenCmd(t *testing.T) {
+	script, out := stubOpener(t)
+	url := "https://github.com/owner/repo"
+
+	msg := OpenCmd(&config.Config{Browser: script}, url)()
+	if opened, ok := msg.(OpenedMsg); !ok || opened.Err != nil || opened.Status() != "Opened "+url {
+		t.Fatalf("got %#v, want the URL reported as opened", msg)
+	}
+	if args := waitForArgs(t, out); !reflect.DeepEqual(args, []string{url}) {
+		t.Errorf("the opener got %q, want the URL", args)
+	}
+
+	msg = OpenCmd(&config.Config{Browser: filepath.Join(t.TempDir(), "missing")}, url)()
+	if opened, ok := msg.(OpenedMsg); !ok || opened.Err == nil || !strings.HasPrefix(opened.Status(), "Could not open "+url) {
+		t.Errorf("got %#v, want the failure reported", msg)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	t.Setenv("BROWSER", "firefox --private-window")
+	if got := Command("chromium"); !reflect.DeepEqual(got, []string{"chromium"}) {
+		t.Errorf("Command prefers %q over the configured command", got)
+	}
+	if got := Command(""); !reflect.DeepEqual(got, []string{"firefox", "--private-window"}) {
+		t.Errorf("Command(\"\") = %q, want $BROWSER", got)
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"ghtui/ghtui/git"
+	"ghtui/ghtui/ui/repositories"
+)
+
+var Ref string
+
+var repoCmd = &cobra.Command{
+	Use:   "repo [owner/name]",
+	Short: "Open a repository directly.",
+	Long: "Open a repository in the file browser, skipping the repository list. " +
+		"Without an argument the repository of the current git checkout is opened at the checked out branch.",
+	Example: "ghtui repo charmbracelet/bubbletea --ref v0.17.0",
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadConfig()
+		var target repositories.Target
+		if len(args) == 1 {
+			parts := strings.Split(args[0], "/")
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				fmt.Println("The repository must be given as owner/name, got " + args[0])
+				os.Exit(1)
+			}
+			target = repositories.Target{Owner: parts[0], Name: parts[1]}
+		} else {
+			dir, err := os.Getwd()
+			if err != nil {
+				fmt.Println("Could not get the working directory", err)
+				os.Exit(1)
+			}
+			checkout, ok := git.DetectCheckout(dir, cfg.Host)
+			if !ok {
+				fmt.Println("Not inside a git checkout with a GitHub origin remote; pass the repository as owner/name.")
+				os.Exit(1)
+			}
+			target = repositories.Target{Owner: checkout.Owner, Name: checkout.Name, Ref: checkout.Ref}
+		}
+		if Ref != "" {
+			target.Ref = Ref
+		}
+		start(cmd, cfg, &target)
+	},
+}
+
+func init() {
+	repoCmd.Flags().StringVarP(&Ref, "ref", "r", "", "Branch, tag or commit to open instead of the default branch")
+	rootCmd.AddCommand(repoCmd)
+}
@@ -9,7 +9,10 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/oauth2"
 
+	"ghtui/ghtui/config"
+	"ghtui/ghtui/git"
 	"ghtui/ghtui/ui"
+	"ghtui/ghtui/ui/repositories"
 )
 
 var Username string
@@ -21,21 +24,43 @@ var rootCmd = &cobra.Command{
 	Long:    "ghtui allows you to browse and interact with GitHub from your terminal.",
 	Example: "ghtui --token <token> --username <username>",
 	Run: func(cmd *cobra.Command, args []string) {
-		Username = getVariable(cmd, "GitHub username", "username", "GITHUB_USERNAME")
-		Token = getVariable(cmd, "GitHub access token", "token", "GITHUB_TOKEN")
-		ctx := context.Background()
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: Token},
-		)
-		tc := oauth2.NewClient(ctx, ts)
-		gh := github.NewClient(tc)
-		if err := ui.NewProgram(Username, gh).Start(); err != nil {
-			fmt.Println("Could not start ghtui", err)
-			os.Exit(1)
+		cfg := loadConfig()
+		var target *repositories.Target
+		if dir, err := os.Getwd(); err == nil {
+			if checkout, ok := git.DetectCheckout(dir, cfg.Host); ok {
+				target = &repositories.Target{Owner: checkout.Owner, Name: checkout.Name, Ref: checkout.Ref}
+			}
 		}
+		start(cmd, cfg, target)
 	},
 }
 
+// loadConfig loads the config file, exiting if it can't be read.
+func loadConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println("Could not load the config", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// start authenticates and runs the UI, opening target directly if set.
+func start(cmd *cobra.Command, cfg *config.Config, target *repositories.Target) {
+	Username = getVariable(cmd, "GitHub username", "username", "GITHUB_USERNAME")
+	Token = getVariable(cmd, "GitHub access token", "token", "GITHUB_TOKEN")
+	ctx := context.Background()
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: Token},
+	)
+	tc := oauth2.NewClient(ctx, ts)
+	gh := github.NewClient(tc)
+	if err := ui.NewProgram(Username, gh, cfg, target).Start(); err != nil {
+		fmt.Println("Could not start ghtui", err)
+		os.Exit(1)
+	}
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&Username, "username", "u", "", "GitHub username")
 	rootCmd.PersistentFlags().StringVarP(&Token, "token", "t", "", "GitHub personal access token")
@@ -46,7 +71,7 @@ func Execute() error {
 }
 
 func getVariable(cmd *cobra.Command, name string, param string, env string) string {
-	value, err := cmd.PersistentFlags().GetString(param)
+	value, err := cmd.Flags().GetString(param)
 	if err != nil {
 		fmt.Println("Could not get "+param, err)
 		os.Exit(1)
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ProtocolHTTPS = "https"
+	ProtocolSSH   = "ssh"
+)
+
+// Config holds the user settings of ghtui, stored as JSON in the user's
+// config directory.
+type Config struct {
+	Clone CloneConfig `json:"clone"`
+	// Browser is the command URLs are opened with, e.g. "firefox". The URL is
+	// appended to its arguments. When empty, $BROWSER or the platform's
+	// default opener is used.
+	Browser string `json:"browser,omitempty"`
+	// Host is a GitHub Enterprise Server host, e.g. "github.example.com".
+	// Checkouts cloned from it are recognized besides those from github.com.
+	Host string `json:"host,omitempty"`
+	// RepositoryFilter is the filter last applied to the repository list.
+	RepositoryFilter RepositoryFilter `json:"repository_filter"`
+
+	path string
+}
+
+// CloneConfig controls how repositories are cloned.
+type CloneConfig struct {
+	// Protocol is either "https" or "ssh".
+	Protocol string `json:"protocol"`
+	// Workspace is the directory layout clones are placed in. {owner} and
+	// {repo} are replaced by the repository's owner and name.
+	Workspace string `json:"workspace"`
+}
+
+// RepositoryFilter narrows down the repository list. Empty fields don't
+// filter anything.
+type RepositoryFilter struct {
+	// Affiliations are any of "owner", "member" and "collaborator".
+	Affiliations []string `json:"affiliations,omitempty"`
+	// Visibilities are any of "public", "private" and "internal".
+	Visibilities []string `json:"visibilities,omitempty"`
+	// Type is "sources" or "forks".
+	Type string `json:"type,omitempty"`
+	// Archived is "hide" or "only".
+	Archived string `json:"archived,omitempty"`
+	// Language is the primary language repositories must have.
+	Language string `json:"language,omitempty"`
+}
+
+// Default returns the settings used when there is no config file.
+func Default() *Config {
+	return &Config{
+		Clone: CloneConfig{
+			Protocol:  ProtocolHTTPS,
+			Workspace: "~/src/{owner}/{repo}",
+		},
+	}
+}
+
+// Path returns the location of the config file. It can be overridden with the
+// GHTUI_CONFIG environment variable.
+func Path() (string, error) {
+	if p := os.Getenv("GHTUI_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ghtui", "config.json"), nil
+}
+
+// Load reads the config file, falling back to the defaults for a missing file
+// or missing settings.
+func Load() (*Config, error) {
+	p, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	c := Default()
+	c.path = p
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save writes the config back to the file it was loaded from.
+func (c *Config) Save() error {
+	if c.path == "" {
+		p, err := Path()
+		if err != nil {
+			return err
+		}
+		c.path = p
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+// Dir returns the directory a repository is cloned into.
+func (c CloneConfig) Dir(owner string, repo string) (string, error) {
+	dir := strings.NewReplacer("{owner}", owner, "{repo}", repo).Replace(c.Workspace)
+	return ExpandHome(dir)
+}
+
+// ExpandHome resolves a leading ~ to the user's home directory.
+func ExpandHome(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, p[1:]), nil
+}
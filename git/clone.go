@@ -0,0 +1,62 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Clone runs git clone for url into dir, passing every progress line git
+// prints to progress.
+func Clone(url string, dir string, progress func(line string)) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "clone", "--progress", url, dir)
+	cmd.Env = environ()
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var last string
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			last = line
+			progress(line)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git clone failed: %s", last)
+	}
+	return nil
+}
+
+// IsRepository reports whether dir is a git checkout.
+func IsRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// scanProgressLines splits on both \n and the \r git uses to redraw its
+// progress counters.
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,84 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	remote, _ := newRemote(t)
+	dir := filepath.Join(t.TempDir(), "owner", "repo")
+
+	var lines []string
+	// A file:// URL makes git transfer a pack and report its progress.
+	if err := Clone("file://"+remote, dir, func(line string) { lines = append(lines, line) }); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "Cloning into") {
+		t.Fatalf("progress starts with %q, want the clone announcement", lines)
+	}
+	var receiving int
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Receiving objects:") {
+			receiving++
+		}
+	}
+	if receiving == 0 {
+		t.Errorf("no progress of the received objects in %q", lines)
+	}
+	if last := lines[len(lines)-1]; !strings.HasSuffix(last, ", done.") {
+		t.Errorf("last progress line is %q, want a done message", last)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "# Hello\n" {
+		t.Errorf("README.md is %q", content)
+	}
+	if !IsRepository(dir) {
+		t.Errorf("%s is not a git checkout", dir)
+	}
+}
+
+func TestCloneFails(t *testing.T) {
+	isolate(t)
+	var lines []string
+	err := Clone("file://"+filepath.Join(t.TempDir(), "missing.git"), filepath.Join(t.TempDir(), "repo"), func(line string) { lines = append(lines, line) })
+	if err == nil {
+		t.Fatal("cloning a missing repository succeeded")
+	}
+	if len(lines) == 0 {
+		t.Fatalf("git reported nothing, error %q", err)
+	}
+	if !strings.Contains(err.Error(), lines[len(lines)-1]) {
+		t.Errorf("error %q doesn't tell what git said last, %q", err, lines[len(lines)-1])
+	}
+}
+
+func TestEnvironKeepsSSHCommand(t *testing.T) {
+	t.Setenv("GIT_SSH_COMMAND", "ssh -i key")
+	for _, v := range environ() {
+		if strings.HasPrefix(v, "GIT_SSH_COMMAND=") && v != "GIT_SSH_COMMAND=ssh -i key" {
+			t.Errorf("environ sets %s over the user's command", v)
+		}
+	}
+
+	t.Setenv("GIT_SSH_COMMAND", "")
+	if !contains(environ(), "GIT_SSH_COMMAND=ssh -oBatchMode=yes") {
+		t.Error("environ doesn't stop ssh from prompting")
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// run runs git in dir and returns its trimmed standard output.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = environ()
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", &Error{Args: args, Stderr: strings.TrimSpace(string(exitErr.Stderr))}
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// environ is the environment git runs with. Neither git nor ssh may prompt
+// for credentials or host keys, which would be drawn over the UI.
+func environ() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -oBatchMode=yes")
+	}
+	return env
+}
+
+// Error is returned when git exits with an error.
+type Error struct {
+	Args   []string
+	Stderr string
+}
+
+func (e *Error) Error() string {
+	return "git " + strings.Join(e.Args, " ") + ": " + e.Stderr
+}
+
+// RemoteURL returns the URL of the named remote of the checkout at dir.
+func RemoteURL(dir string, remote string) (string, error) {
+	return run(dir, "remote", "get-url", remote)
+}
+
+// CurrentRef returns the branch checked out at dir, or the commit SHA when
+// HEAD is detached.
+func CurrentRef(dir string) (string, error) {
+	if branch, err := run(dir, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		return branch, nil
+	}
+	return run(dir, "rev-parse", "HEAD")
+}
+
+// Checkout describes the GitHub repository a local checkout was cloned from.
+type Checkout struct {
+	Dir   string
+	Owner string
+	Name  string
+	Ref   string
+}
+
+// DetectCheckout looks up the GitHub repository behind the origin remote of
+// the checkout containing dir, on github.com or enterpriseHost.
+func DetectCheckout(dir string, enterpriseHost string) (Checkout, bool) {
+	top, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return Checkout{}, false
+	}
+	remote, err := RemoteURL(top, "origin")
+	if err != nil {
+		return Checkout{}, false
+	}
+	owner, name, ok := ParseRemoteURL(remote, enterpriseHost)
+	if !ok {
+		return Checkout{}, false
+	}
+	ref, err := CurrentRef(top)
+	if err != nil {
+		return Checkout{}, false
+	}
+	return Checkout{Dir: top, Owner: owner, Name: name, Ref: ref}, true
+}
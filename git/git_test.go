@@ -0,0 +1,56 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// isolate keeps the user's git config out of a test and gives its commits
+// an author.
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+}
+
+// mustRun runs git in dir and fails the test if it fails.
+func mustRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := run(dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// commitFile writes a file in the checkout at dir and commits it.
+func commitFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, dir, "add", name)
+	mustRun(t, dir, "commit", "-m", "Change "+name)
+	return mustRun(t, dir, "rev-parse", "HEAD")
+}
+
+// newRemote creates a bare repository with one commit on main, pushed from
+// the returned work checkout.
+func newRemote(t *testing.T) (remote string, work string) {
+	t.Helper()
+	isolate(t)
+	root := t.TempDir()
+	remote = filepath.Join(root, "remote.git")
+	work = filepath.Join(root, "work")
+	mustRun(t, root, "init", "--bare", "--initial-branch=main", remote)
+	mustRun(t, root, "init", "--initial-branch=main", work)
+	commitFile(t, work, "README.md", "# Hello\n")
+	mustRun(t, work, "remote", "add", "origin", remote)
+	mustRun(t, work, "push", "origin", "main")
+	return remote, work
+}
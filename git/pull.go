@@ -0,0 +1,142 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PullHead is the head of a pull request to check out.
+type PullHead struct {
+	Number int
+	// Ref is the head branch, in the repository of the pull request or in
+	// the fork at URL.
+	Ref string
+	// URL is the fork the head branch is in, or empty when it is in the
+	// repository itself. Both are empty when the fork was deleted.
+	URL string
+}
+
+// PullCheckout is what CheckoutPull did.
+type PullCheckout struct {
+	Branch  string
+	SHA     string
+	Created bool
+}
+
+// DirtyError is returned when the working tree has changes that checking
+// out another branch could lose.
+type DirtyError struct {
+	Files []string
+}
+
+func (e *DirtyError) Error() string {
+	return "the working tree has uncommitted changes: " + strings.Join(e.Files, ", ")
+}
+
+// ConflictError is returned when the head can't be checked out over the
+// local branch or the working tree.
+type ConflictError struct {
+	Branch string
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return "could not check out " + e.Branch + ": " + e.Reason
+}
+
+// CheckoutPull fetches the head of a pull request from remote, or from the
+// fork it comes from, and checks it out in dir as branch. An existing branch
+// is fast-forwarded, but never reset over commits of its own.
+func CheckoutPull(dir string, remote string, head PullHead, branch string) (PullCheckout, error) {
+	result := PullCheckout{Branch: branch}
+	changed, err := run(dir, "diff", "--name-only", "HEAD")
+	if err != nil {
+		return result, err
+	}
+	if changed != "" {
+		return result, &DirtyError{Files: strings.Split(changed, "\n")}
+	}
+
+	// A branch of the repository itself is fetched into its remote tracking
+	// branch, to be tracked like any other.
+	tracking := ""
+	switch {
+	case head.Ref == "":
+		err = errors.New("the head branch is gone")
+	case head.URL == "":
+		tracking = "refs/remotes/" + remote + "/" + head.Ref
+		_, err = run(dir, "fetch", remote, "+refs/heads/"+head.Ref+":"+tracking)
+	default:
+		_, err = run(dir, "fetch", head.URL, "refs/heads/"+head.Ref)
+	}
+	// fallback is set when the head came from the pull request's ref, with
+	// no branch left to track.
+	fallback := err != nil
+	if err != nil {
+		// The head branch or its fork may have been deleted, but GitHub keeps
+		// the head of every pull request.
+		tracking = ""
+		if _, pullErr := run(dir, "fetch", remote, fmt.Sprintf("refs/pull/%d/head", head.Number)); pullErr != nil {
+			return result, err
+		}
+	}
+	if result.SHA, err = run(dir, "rev-parse", "FETCH_HEAD"); err != nil {
+		return result, err
+	}
+
+	if _, err := run(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		result.Created = true
+		if _, err := run(dir, "checkout", "-b", branch, result.SHA); err != nil {
+			return result, conflict(branch, err)
+		}
+		if fallback {
+			return result, nil
+		}
+		return result, setUpstream(dir, branch, remote, head, tracking)
+	}
+
+	if _, err := run(dir, "merge-base", "--is-ancestor", branch, result.SHA); err != nil {
+		return result, &ConflictError{Branch: branch, Reason: "it has commits that aren't in the pull request"}
+	}
+	current, _ := CurrentRef(dir)
+	if current == branch {
+		if _, err := run(dir, "merge", "--ff-only", result.SHA); err != nil {
+			return result, conflict(branch, err)
+		}
+		return result, nil
+	}
+	if _, err := run(dir, "branch", "--force", branch, result.SHA); err != nil {
+		return result, err
+	}
+	if _, err := run(dir, "checkout", branch); err != nil {
+		return result, conflict(branch, err)
+	}
+	return result, nil
+}
+
+// setUpstream makes git pull fetch the head branch of the pull request into
+// branch, from the fork when it comes from one.
+func setUpstream(dir string, branch string, remote string, head PullHead, tracking string) error {
+	if tracking != "" {
+		_, err := run(dir, "branch", "--set-upstream-to", remote+"/"+head.Ref, branch)
+		return err
+	}
+	if head.URL == "" {
+		return nil
+	}
+	if _, err := run(dir, "config", "branch."+branch+".remote", head.URL); err != nil {
+		return err
+	}
+	_, err := run(dir, "config", "branch."+branch+".merge", "refs/heads/"+head.Ref)
+	return err
+}
+
+// conflict turns what git said about a failed checkout or merge into a
+// ConflictError.
+func conflict(branch string, err error) error {
+	if gitErr, ok := err.(*Error); ok {
+		return &ConflictError{Branch: branch, Reason: gitErr.Stderr}
+	}
+	return err
+}
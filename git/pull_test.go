@@ -0,0 +1,131 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newPullCheckout clones a new remote and pushes a feature branch to it from
+// the work checkout, returning the clone, the work checkout and the head.
+func newPullCheckout(t *testing.T) (local string, work string, head string) {
+	t.Helper()
+	remote, work := newRemote(t)
+	mustRun(t, work, "checkout", "-b", "feature")
+	head = commitFile(t, work, "feature.txt", "one\n")
+	mustRun(t, work, "push", "origin", "feature")
+
+	local = filepath.Join(t.TempDir(), "local")
+	mustRun(t, work, "clone", remote, local)
+	return local, work, head
+}
+
+func TestCheckoutPullNewBranch(t *testing.T) {
+	local, _, head := newPullCheckout(t)
+
+	result, err := CheckoutPull(local, "origin", PullHead{Number: 1, Ref: "feature"}, "feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Created || result.SHA != head || result.Branch != "feature" {
+		t.Errorf("got %+v, want feature created at %s", result, head)
+	}
+	if ref, _ := CurrentRef(local); ref != "feature" {
+		t.Errorf("checked out %s, want feature", ref)
+	}
+	if upstream := mustRun(t, local, "rev-parse", "--abbrev-ref", "feature@{upstream}"); upstream != "origin/feature" {
+		t.Errorf("feature tracks %s, want origin/feature", upstream)
+	}
+}
+
+func TestCheckoutPullFastForward(t *testing.T) {
+	local, work, _ := newPullCheckout(t)
+	if _, err := CheckoutPull(local, "origin", PullHead{Number: 1, Ref: "feature"}, "feature"); err != nil {
+		t.Fatal(err)
+	}
+	head := commitFile(t, work, "feature.txt", "two\n")
+	mustRun(t, work, "push", "origin", "feature")
+
+	for _, current := range []string{"feature", "main"} {
+		mustRun(t, local, "checkout", current)
+		result, err := CheckoutPull(local, "origin", PullHead{Number: 1, Ref: "feature"}, "feature")
+		if err != nil {
+			t.Fatalf("from %s: %s", current, err)
+		}
+		if result.Created || result.SHA != head {
+			t.Errorf("from %s: got %+v, want feature fast-forwarded to %s", current, result, head)
+		}
+		if sha := mustRun(t, local, "rev-parse", "HEAD"); sha != head {
+			t.Errorf("from %s: HEAD is at %s, want %s", current, sha, head)
+		}
+	}
+}
+
+func TestCheckoutPullConflict(t *testing.T) {
+	local, _, _ := newPullCheckout(t)
+	if _, err := CheckoutPull(local, "origin", PullHead{Number: 1, Ref: "feature"}, "feature"); err != nil {
+		t.Fatal(err)
+	}
+	own := commitFile(t, local, "local.txt", "mine\n")
+
+	_, err := CheckoutPull(local, "origin", PullHead{Number: 1, Ref: "feature"}, "feature")
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.Branch != "feature" {
+		t.Fatalf("got %v, want a ConflictError for feature", err)
+	}
+	if sha := mustRun(t, local, "rev-parse", "feature"); sha != own {
+		t.Errorf("feature was moved from %s to %s", own, sha)
+	}
+}
+
+func TestCheckoutPullDirty(t *testing.T) {
+	local, _, _ := newPullCheckout(t)
+	if err := os.WriteFile(filepath.Join(local, "README.md"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := CheckoutPull(local, "origin", PullHead{Number: 1, Ref: "feature"}, "feature")
+	var dirty *DirtyError
+	if !errors.As(err, &dirty) {
+		t.Fatalf("got %v, want a DirtyError", err)
+	}
+	if len(dirty.Files) != 1 || dirty.Files[0] != "README.md" {
+		t.Errorf("dirty files are %q, want README.md", dirty.Files)
+	}
+	if ref, _ := CurrentRef(local); ref != "main" {
+		t.Errorf("checked out %s, want main", ref)
+	}
+}
+
+func TestCheckoutPullDeletedHead(t *testing.T) {
+	local, work, head := newPullCheckout(t)
+	mustRun(t, work, "push", "origin", "feature:refs/pull/7/head")
+	mustRun(t, work, "push", "origin", "--delete", "feature")
+
+	tests := []struct {
+		name string
+		head PullHead
+	}{
+		{"deleted branch", PullHead{Number: 7, Ref: "feature"}},
+		{"deleted fork", PullHead{Number: 7}},
+		{"unreachable fork", PullHead{Number: 7, Ref: "feature", URL: filepath.Join(t.TempDir(), "gone.git")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mustRun(t, local, "checkout", "main")
+			// The previous case may have created the branch.
+			_, _ = run(local, "branch", "-D", "pr-7")
+			result, err := CheckoutPull(local, "origin", tt.head, "pr-7")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Created || result.SHA != head {
+				t.Errorf("got %+v, want pr-7 created at %s", result, head)
+			}
+			if upstream, err := run(local, "config", "branch.pr-7.remote"); err == nil {
+				t.Errorf("pr-7 tracks %s, which it wasn't fetched from", upstream)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package git
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ParseRemoteURL extracts the owner and repository name from a GitHub remote
+// URL in any of the forms git accepts, e.g. https://github.com/owner/repo.git,
+// git@github.com:owner/repo.git or ssh://git@github.com/owner/repo. Remotes
+// on other hosts than github.com and the optional enterprise host aren't
+// GitHub repositories.
+func ParseRemoteURL(remote string, enterpriseHost string) (owner string, name string, ok bool) {
+	remote = strings.TrimSpace(remote)
+	var host, p string
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+		host, p = u.Hostname(), u.Path
+	} else if i := strings.Index(remote, ":"); i >= 0 && !strings.Contains(remote[:i], "/") {
+		// scp-like syntax: [user@]host:owner/repo.git
+		host, p = remote[:i], remote[i+1:]
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+	} else {
+		return "", "", false
+	}
+	if !strings.EqualFold(host, "github.com") && (enterpriseHost == "" || !strings.EqualFold(host, enterpriseHost)) {
+		return "", "", false
+	}
+
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	parts := strings.Split(p, "/")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	owner, name = parts[len(parts)-2], parts[len(parts)-1]
+	if owner == "" || name == "" {
+		return "", "", false
+	}
+	return owner, name, true
+}
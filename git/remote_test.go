@@ -0,0 +1,32 @@
+package git
+
+import "testing"
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		host   string
+		ok     bool
+	}{
+		{"https://github.com/owner/repo.git", "", true},
+		{"git@github.com:owner/repo.git", "", true},
+		{"ssh://git@github.com:22/owner/repo", "", true},
+		{"https://gitlab.com/owner/repo.git", "", false},
+		{"git@bitbucket.org:owner/repo.git", "", false},
+		{"https://github.example.com/owner/repo.git", "", false},
+		{"https://github.example.com/owner/repo.git", "github.example.com", true},
+		{"git@GitHub.Example.com:owner/repo.git", "github.example.com", true},
+		{"git@gitlab.com:owner/repo.git", "github.example.com", false},
+		{"/srv/git/owner/repo.git", "", false},
+	}
+	for _, tt := range tests {
+		owner, name, ok := ParseRemoteURL(tt.remote, tt.host)
+		if ok != tt.ok {
+			t.Errorf("ParseRemoteURL(%q, %q) ok = %v, want %v", tt.remote, tt.host, ok, tt.ok)
+			continue
+		}
+		if ok && (owner != "owner" || name != "repo") {
+			t.Errorf("ParseRemoteURL(%q, %q) = %s/%s, want owner/repo", tt.remote, tt.host, owner, name)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/progress"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ghtui/ghtui/config"
+)
+
+// DownloadProgress is reported by a running download. The last report of a
+// download has Finished set, and Err set if it failed.
+type DownloadProgress struct {
+	Name       string
+	Dest       string
+	Bytes      int64
+	TotalBytes int64
+	Files      int
+	TotalFiles int
+	Finished   bool
+	Err        error
+
+	updates  chan DownloadProgress
+	reported int64
+}
+
+// Report sends the progress so far to the screen. It doesn't wait for the
+// screen, which may have been left: a report nobody read yet is replaced, so
+// the last one is always kept.
+func (p *DownloadProgress) Report() {
+	for {
+		select {
+		case p.updates <- *p:
+			return
+		default:
+		}
+		select {
+		case <-p.updates:
+		default:
+		}
+	}
+}
+
+// Write counts the bytes written through it, so a download can be copied
+// through the progress.
+func (p *DownloadProgress) Write(b []byte) (int, error) {
+	p.Bytes += int64(len(b))
+	// Don't flood the UI with an update for every chunk.
+	if p.Bytes-p.reported >= 64*1024 {
+		p.reported = p.Bytes
+		p.Report()
+	}
+	return len(b), nil
+}
+
+// DownloadFunc saves something to dest, reporting to p as it goes. Existing
+// files may only be replaced when overwrite is set.
+type DownloadFunc func(dest string, overwrite bool, p *DownloadProgress) error
+
+// DownloadProgressMsg carries a report of the running download to
+// Download.Update.
+type DownloadProgressMsg struct {
+	progress DownloadProgress
+	updates  <-chan DownloadProgress
+}
+
+// Download asks where to save something, runs the download and shows its
+// progress in the status bar of a screen.
+type Download struct {
+	prompting bool
+	input     input.Model
+	name      string
+	run       DownloadFunc
+	// overwrite is the existing destination the user is asked to replace.
+	overwrite string
+	active    bool
+	progress  DownloadProgress
+	bar       progress.Model
+	// updates are the reports of the running download. Reports of another
+	// screen's download are ignored.
+	updates <-chan DownloadProgress
+}
+
+// Prompt asks where to save name, suggesting the path suggestion. run is
+// started once the user confirmed it.
+func (d *Download) Prompt(name string, suggestion string, run DownloadFunc) tea.Cmd {
+	d.input = input.NewModel()
+	d.input.Prompt = "Save to: "
+	d.input.SetValue(suggestion)
+	d.input.CursorEnd()
+	d.input.Focus()
+	d.prompting = true
+	d.name = name
+	d.run = run
+	return input.Blink
+}
+
+// Prompting reports whether the download takes the key presses.
+func (d Download) Prompting() bool {
+	return d.prompting
+}
+
+// Active reports whether a download is running.
+func (d Download) Active() bool {
+	return d.active
+}
+
+// Update handles the keys of the prompt and the progress of the running
+// download. status is what to tell the user, if anything.
+func (d Download) Update(msg tea.Msg) (Download, tea.Cmd, string) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if !d.prompting {
+			return d, nil, ""
+		}
+		return d.updatePrompt(msg)
+	case DownloadProgressMsg:
+		if msg.updates != d.updates {
+			return d, nil, ""
+		}
+		d.progress = msg.progress
+		if !msg.progress.Finished {
+			return d, waitForDownload(msg.updates), ""
+		}
+		d.active = false
+		d.updates = nil
+		if msg.progress.Err != nil {
+			return d, nil, "Download of " + msg.progress.Name + " failed: " + msg.progress.Err.Error()
+		}
+		return d, nil, "Saved " + msg.progress.Name + " to " + msg.progress.Dest
+	}
+	return d, nil, ""
+}
+
+func (d Download) updatePrompt(msg tea.KeyMsg) (Download, tea.Cmd, string) {
+	if dest := d.overwrite; dest != "" {
+		d.overwrite = ""
+		d.prompting = false
+		if msg.String() != "y" {
+			return d, nil, ""
+		}
+		return d.start(dest, true)
+	}
+	switch msg.Type {
+	case tea.KeyEscape:
+		d.prompting = false
+		return d, nil, ""
+	case tea.KeyEnter:
+		d.prompting = false
+		p := strings.TrimSpace(d.input.Value())
+		if p == "" {
+			return d, nil, "No destination given."
+		}
+		dest, err := config.ExpandHome(p)
+		if err != nil {
+			return d, nil, err.Error()
+		}
+		if d.active {
+			return d, nil, "A download is already running."
+		}
+		if _, err := os.Stat(dest); err == nil {
+			d.prompting = true
+			d.overwrite = dest
+			return d, nil, ""
+		}
+		return d.start(dest, false)
+	}
+	var cmd tea.Cmd
+	d.input, cmd = d.input.Update(msg)
+	return d, cmd, ""
+}
+
+func (d Download) start(dest string, overwrite bool) (Download, tea.Cmd, string) {
+	// Holds the latest report, see DownloadProgress.Report.
+	updates := make(chan DownloadProgress, 1)
+	run := d.run
+	p := DownloadProgress{Name: d.name, Dest: dest, updates: updates}
+	go func() {
+		p.Err = run(dest, overwrite, &p)
+		p.Finished = true
+		p.Report()
+	}()
+
+	d.active = true
+	d.updates = updates
+	d.progress = DownloadProgress{Name: d.name, Dest: dest}
+	d.bar = progress.NewModel(progress.WithDefaultGradient(), progress.WithWidth(30))
+	return d, waitForDownload(updates), ""
+}
+
+func waitForDownload(updates <-chan DownloadProgress) tea.Cmd {
+	return func() tea.Msg {
+		return DownloadProgressMsg{progress: <-updates, updates: updates}
+	}
+}
+
+// View renders the download prompt or progress for the status bar.
+func (d Download) View() string {
+	if d.overwrite != "" {
+		return d.overwrite + " already exists. Overwrite it? (y/n)"
+	}
+	if d.prompting {
+		return d.input.View()
+	}
+	if !d.active {
+		return ""
+	}
+	p := d.progress
+	s := "Downloading " + p.Name + " "
+	if p.TotalFiles > 0 {
+		s += fmt.Sprintf("%d/%d files ", p.Files, p.TotalFiles)
+	}
+	if p.TotalBytes > 0 {
+		return s + d.bar.ViewAs(float64(p.Bytes)/float64(p.TotalBytes)) + " " +
+			FormatBytes(p.Bytes) + " / " + FormatBytes(p.TotalBytes)
+	}
+	return s + FormatBytes(p.Bytes)
+}
+
+// WriteFile copies r into the file at dest, counting the bytes in p. An
+// existing file is only replaced when overwrite is set.
+func WriteFile(dest string, overwrite bool, r io.Reader, p *DownloadProgress) (err error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(dest, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists", dest)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	_, err = io.Copy(io.MultiWriter(file, p), r)
+	return err
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// EditorMsg asks the program to suspend while the user edits text in their
+// editor. The message Done returns for the edited text is sent once the
+// program resumes.
+type EditorMsg struct {
+	// Name is the file name the text is edited under, so the editor can
+	// pick the right syntax.
+	Name string
+	Text string
+	Done func(text string, err error) tea.Msg
+}
+
+// Edit opens text in the user's editor.
+func Edit(name string, text string, done func(text string, err error) tea.Msg) tea.Cmd {
+	return Cmd(EditorMsg{Name: name, Text: text, Done: done})
+}
+
+// RunEditor writes text to a temporary file, runs $VISUAL or $EDITOR on it in
+// the terminal and returns what was saved.
+func RunEditor(name string, text string) (string, error) {
+	dir, err := os.MkdirTemp("", "ghtui-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	if name = filepath.Base(name); name == "." || name == string(filepath.Separator) {
+		name = "text.md"
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		return "", err
+	}
+
+	// The editor may come with arguments, like "code --wait".
+	args := strings.Fields(editor())
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w", args[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func editor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			return value
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
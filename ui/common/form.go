@@ -0,0 +1,46 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// formLabelWidth is the width of the label column of a form.
+const formLabelWidth = 14
+
+// FormCursor marks the focused line of a form.
+func FormCursor(focused bool) string {
+	if focused {
+		return MatchStyle().Render("> ")
+	}
+	return "  "
+}
+
+// FormField renders a line of a form with its label in the label column.
+// An empty label lines value up under the values of the fields.
+func FormField(focused bool, label string, value string) string {
+	return FormCursor(focused) + lipgloss.NewStyle().Width(formLabelWidth).Render(label) + value
+}
+
+// Checkbox renders whether an option of a form is checked.
+func Checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// FormView renders the lines of a form in a box, followed by its error, if
+// any, and the help line.
+func FormView(lines []string, err string, help string) string {
+	if err != "" {
+		lines = append(lines, ErrorStyle().Render(err))
+	}
+	lines = append(lines, lipgloss.NewStyle().Foreground(GrayColor()).Render(help))
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(GrayColor()).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
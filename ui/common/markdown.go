@@ -0,0 +1,21 @@
+package common
+
+import "github.com/charmbracelet/glamour"
+
+// RenderMarkdown renders markdown for the terminal, wrapped at width. The
+// source is returned unchanged if it can't be rendered.
+func RenderMarkdown(source string, width int) string {
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithAutoStyle(),
+		glamour.WithEmoji(),
+		glamour.WithWordWrap(width),
+	)
+	if err != nil {
+		return source
+	}
+	rendered, err := renderer.Render(source)
+	if err != nil {
+		return source
+	}
+	return rendered
+}
@@ -33,6 +33,10 @@ func WhiteColor() lipgloss.AdaptiveColor {
 	return whiteColor
 }
 
+func GrayColor() lipgloss.AdaptiveColor {
+	return grayColor
+}
+
 func AppStyle() lipgloss.Style {
 	return appStyle
 }
@@ -61,6 +65,36 @@ func PaneSelectedItemStyle() lipgloss.Style {
 	return lipgloss.NewStyle().Foreground(whiteColor).Background(infoColor)
 }
 
+func MatchStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(successColor).Bold(true)
+}
+
+func GutterStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(grayColor)
+}
+
+// StatusIcon renders the status of a workflow run, job, step or check run,
+// or its conclusion once completed.
+func StatusIcon(status string, conclusion string) string {
+	switch status {
+	case "completed":
+	case "in_progress":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(warning)).Render("●")
+	default:
+		return lipgloss.NewStyle().Foreground(grayColor).Render("○")
+	}
+	switch conclusion {
+	case "success":
+		return MatchStyle().Render("✓")
+	case "failure", "timed_out", "startup_failure", "action_required":
+		return ErrorStyle().Render("✗")
+	case "cancelled", "skipped":
+		return lipgloss.NewStyle().Foreground(grayColor).Render("⊘")
+	default:
+		return lipgloss.NewStyle().Foreground(grayColor).Render("-")
+	}
+}
+
 func paneStyle(color lipgloss.AdaptiveColor, width int, height int) lipgloss.Style {
 	return lipgloss.NewStyle().
 		BorderForeground(color).
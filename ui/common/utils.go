@@ -2,8 +2,10 @@ package common
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/chroma/lexers"
 	"github.com/alecthomas/chroma/quick"
@@ -42,3 +44,68 @@ func Highlight(name string, contents string) string {
 	}
 	return buff.String()
 }
+
+// FormatBytes renders a byte count using binary units, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// FormatDuration renders d the way the Actions UI does, e.g. "3m 12s".
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// RelativeTime renders how long ago t was, e.g. "3 days ago".
+func RelativeTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return Plural(int(d.Minutes()), "minute") + " ago"
+	case d < 24*time.Hour:
+		return Plural(int(d.Hours()), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return Plural(int(d.Hours()/24), "day") + " ago"
+	case d < 365*24*time.Hour:
+		return Plural(int(d.Hours()/24/30), "month") + " ago"
+	default:
+		return Plural(int(d.Hours()/24/365), "year") + " ago"
+	}
+}
+
+// Plural counts n of unit, e.g. "1 file" or "3 files".
+func Plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// ShortSHA abbreviates a commit SHA the way GitHub shows it.
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,274 @@
+package gists
+
+import (
+	"context"
+	"strings"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// gistSavedMsg reports a gist that was created, changed or forked.
+type gistSavedMsg struct {
+	gist   *github.Gist
+	status string
+}
+
+// gistEditedMsg carries a file back from the editor. A nil gist means the
+// file of a new gist, described by form.
+type gistEditedMsg struct {
+	gist     *github.Gist
+	form     gistForm
+	file     string
+	original string
+	text     string
+	err      error
+}
+
+type gistStarredMsg struct {
+	id      string
+	starred bool
+	status  string
+}
+
+type gistDeletedMsg string
+
+// gistForm describes a new gist before its first file is written.
+type gistForm struct {
+	description input.Model
+	file        input.Model
+	public      bool
+	// focus is 0 for the description, 1 for the file name and 2 for public.
+	focus int
+	err   string
+}
+
+func openGistForm(m Model) (Model, tea.Cmd) {
+	form := gistForm{}
+	form.description = input.NewModel()
+	form.description.Prompt = ""
+	form.description.Focus()
+	form.file = input.NewModel()
+	form.file.Prompt = ""
+	form.file.Placeholder = "snippet.go"
+	m.form = &form
+	return m, input.Blink
+}
+
+func updateGistForm(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	form := m.form
+	switch msg.String() {
+	case "esc":
+		m.form = nil
+		return m, nil
+	case "tab", "down":
+		form.setFocus((form.focus + 1) % 3)
+		return m, input.Blink
+	case "shift+tab", "up":
+		form.setFocus((form.focus + 2) % 3)
+		return m, input.Blink
+	case "enter":
+		name := strings.TrimSpace(form.file.Value())
+		if name == "" {
+			form.err = "a file name is required"
+			return m, nil
+		}
+		m.form = nil
+		values := *form
+		return m, common.Edit(name, "", func(text string, err error) tea.Msg {
+			return gistEditedMsg{form: values, file: name, text: text, err: err}
+		})
+	}
+
+	var cmd tea.Cmd
+	switch form.focus {
+	case 0:
+		form.description, cmd = form.description.Update(msg)
+	case 1:
+		form.file, cmd = form.file.Update(msg)
+	case 2:
+		if msg.String() == " " || msg.String() == "x" {
+			form.public = !form.public
+		}
+	}
+	return m, cmd
+}
+
+func (f *gistForm) setFocus(focus int) {
+	f.description.Blur()
+	f.file.Blur()
+	f.focus = focus
+	switch focus {
+	case 0:
+		f.description.Focus()
+	case 1:
+		f.file.Focus()
+	}
+}
+
+func (f gistForm) View() string {
+	lines := []string{
+		"New gist",
+		"",
+		common.FormField(f.focus == 0, "Description", f.description.View()),
+		common.FormField(f.focus == 1, "File name *", f.file.View()),
+		common.FormField(f.focus == 2, "Public", common.Checkbox(f.public)),
+		"",
+	}
+	return common.FormView(lines, f.err, "tab/↑/↓ move · space toggle · enter write the file in $EDITOR · esc cancel")
+}
+
+// edit opens the selected file in the editor, or asks for a new description
+// when a gist is selected.
+func (m Model) edit() (Model, tea.Cmd) {
+	r, ok := m.selected()
+	if !ok {
+		return m, nil
+	}
+	if r.file == "" {
+		m.describing = r.gist
+		m.input = input.NewModel()
+		m.input.Prompt = "Description: "
+		m.input.SetValue(r.gist.GetDescription())
+		m.input.CursorEnd()
+		m.input.Focus()
+		return m, input.Blink
+	}
+	gist, ok := m.details[r.gist.GetID()]
+	if !ok {
+		m.statusMsg = "The gist is still loading."
+		return m, nil
+	}
+	file := r.file
+	original := fileContent(gist, file)
+	return m, common.Edit(file, original, func(text string, err error) tea.Msg {
+		return gistEditedMsg{gist: gist, file: file, original: original, text: text, err: err}
+	})
+}
+
+func updateDescriptionPrompt(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEscape:
+		m.describing = nil
+		return m, nil
+	case tea.KeyEnter:
+		gist := m.describing
+		m.describing = nil
+		description := strings.TrimSpace(m.input.Value())
+		if description == gist.GetDescription() {
+			return m, nil
+		}
+		m.statusMsg = "Saving the description..."
+		return m, m.save(gist, &github.Gist{Description: github.String(description)}, "Saved the description.")
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateGistEdited creates or updates a gist with a file the user saved.
+func updateGistEdited(m Model, msg gistEditedMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.statusMsg = "Could not edit " + msg.file + ": " + msg.err.Error()
+		return m, nil
+	}
+	// GitHub deletes the files of a gist that are saved empty, and won't
+	// create a gist without content.
+	if strings.TrimSpace(msg.text) == "" {
+		m.statusMsg = msg.file + " was left empty, so nothing was saved."
+		return m, nil
+	}
+
+	files := map[github.GistFilename]github.GistFile{
+		github.GistFilename(msg.file): {Content: github.String(msg.text)},
+	}
+	if msg.gist == nil {
+		m.statusMsg = "Creating the gist..."
+		return m, m.create(&github.Gist{
+			Description: github.String(strings.TrimSpace(msg.form.description.Value())),
+			Public:      github.Bool(msg.form.public),
+			Files:       files,
+		})
+	}
+	if msg.text == msg.original {
+		m.statusMsg = "No changes to " + msg.file + "."
+		return m, nil
+	}
+	m.statusMsg = "Saving " + msg.file + "..."
+	return m, m.save(msg.gist, &github.Gist{Files: files}, "Saved "+msg.file+".")
+}
+
+// updateGistSaved shows the gist as GitHub returned it, which includes the
+// contents of its files.
+func updateGistSaved(m Model, msg gistSavedMsg) (Model, tea.Cmd) {
+	m.statusMsg = msg.status
+	m.details[msg.gist.GetID()] = msg.gist
+	return m, m.loadGists(msg.gist.GetID())
+}
+
+func (m Model) create(gist *github.Gist) tea.Cmd {
+	return func() tea.Msg {
+		created, _, err := m.gh.Gists.Create(context.Background(), gist)
+		if err != nil {
+			return statusMsg("Could not create the gist: " + err.Error())
+		}
+		return gistSavedMsg{gist: created, status: "Created the gist " + title(created) + "."}
+	}
+}
+
+// save applies changes to gist. Only the fields set in changes are updated.
+func (m Model) save(gist *github.Gist, changes *github.Gist, status string) tea.Cmd {
+	return func() tea.Msg {
+		saved, _, err := m.gh.Gists.Edit(context.Background(), gist.GetID(), changes)
+		if err != nil {
+			return statusMsg("Could not save " + title(gist) + ": " + err.Error())
+		}
+		return gistSavedMsg{gist: saved, status: status}
+	}
+}
+
+func (m Model) fork(gist *github.Gist) tea.Cmd {
+	return func() tea.Msg {
+		forked, _, err := m.gh.Gists.Fork(context.Background(), gist.GetID())
+		if err != nil {
+			return statusMsg("Could not fork " + title(gist) + ": " + err.Error())
+		}
+		// The fork comes back without its files.
+		if full, _, err := m.gh.Gists.Get(context.Background(), forked.GetID()); err == nil {
+			forked = full
+		}
+		return gistSavedMsg{gist: forked, status: "Forked " + title(gist) + "."}
+	}
+}
+
+func (m Model) toggleStar(gist *github.Gist) tea.Cmd {
+	id := gist.GetID()
+	starred := m.starred[id]
+	return func() tea.Msg {
+		var err error
+		if starred {
+			_, err = m.gh.Gists.Unstar(context.Background(), id)
+		} else {
+			_, err = m.gh.Gists.Star(context.Background(), id)
+		}
+		if err != nil {
+			return gistStarredMsg{id: id, starred: starred, status: "Could not star " + title(gist) + ": " + err.Error()}
+		}
+		if starred {
+			return gistStarredMsg{id: id, starred: false, status: "Unstarred " + title(gist) + "."}
+		}
+		return gistStarredMsg{id: id, starred: true, status: "Starred " + title(gist) + "."}
+	}
+}
+
+func (m Model) delete(gist *github.Gist) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.gh.Gists.Delete(context.Background(), gist.GetID()); err != nil {
+			return statusMsg("Could not delete " + title(gist) + ": " + err.Error())
+		}
+		return gistDeletedMsg(gist.GetID())
+	}
+}
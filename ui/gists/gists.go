@@ -0,0 +1,484 @@
+package gists
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/browser"
+	"ghtui/ghtui/config"
+	"ghtui/ghtui/ui/common"
+	"ghtui/ghtui/ui/repositories/repository/pane"
+)
+
+type gistsLoadedMsg struct {
+	gists []*github.Gist
+	// selectID is the gist to select once loaded, if any.
+	selectID string
+}
+type gistLoadedMsg struct {
+	id      string
+	gist    *github.Gist
+	starred bool
+	err     error
+}
+type errorMsg error
+type statusMsg string
+
+// row is a gist, or one of its files, in the left pane of the gists screen.
+type row struct {
+	gist *github.Gist
+	file string
+}
+
+// confirmation asks before running an action on a gist.
+type confirmation struct {
+	prompt string
+	action tea.Cmd
+}
+
+type status int
+
+const (
+	statusLoading status = iota
+	statusReady
+)
+
+// Model lists the gists of the authenticated user with their files. The
+// files of the selected gist are fetched when it is first shown, as the list
+// leaves their contents out.
+type Model struct {
+	Done bool
+
+	user      *github.User
+	gh        *github.Client
+	config    *config.Config
+	spinner   spinner.Model
+	status    status
+	statusMsg string
+	rows      []row
+	index     int
+	paneIndex int
+	leftPane  pane.Model
+	rightPane pane.Model
+	// shown is the row whose details are in the right pane.
+	shown   int
+	details map[string]*github.Gist
+	starred map[string]bool
+	loading map[string]bool
+	confirm *confirmation
+	// describing is the gist whose description is being edited, if any.
+	describing *github.Gist
+	input      input.Model
+	form       *gistForm
+}
+
+func NewModel(user *github.User, gh *github.Client, cfg *config.Config) Model {
+	width, height := common.ScreenSize()
+	baseWidth := width / 3
+	top, right, bottom, _ := common.AppStyle().GetPadding()
+	paneHeight := height - top - bottom
+	return Model{
+		user:      user,
+		gh:        gh,
+		config:    cfg,
+		spinner:   common.NewSpinnerModel(),
+		status:    statusLoading,
+		leftPane:  pane.NewModel(baseWidth-right, paneHeight-3, true),
+		rightPane: pane.NewModel(baseWidth*2-right, paneHeight-3, false),
+		shown:     -1,
+		details:   map[string]*github.Gist{},
+		starred:   map[string]bool{},
+		loading:   map[string]bool{},
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.loadGists(""), spinner.Tick)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.form != nil {
+			return updateGistForm(m, msg)
+		}
+		if m.describing != nil {
+			return updateDescriptionPrompt(m, msg)
+		}
+		if m.confirm != nil {
+			confirm := m.confirm
+			m.confirm = nil
+			if msg.String() == "y" {
+				return m, confirm.action
+			}
+			return m, nil
+		}
+		if m.paneIndex == 1 && m.rightPane.Prompting() {
+			m.rightPane, cmd = m.rightPane.Update(msg)
+			return m, cmd
+		}
+		switch msg.String() {
+		case "esc":
+			if m.paneIndex == 1 {
+				m.paneIndex = 0
+			} else {
+				m.Done = true
+			}
+		case "tab":
+			m.paneIndex ^= 1
+		case "up", "k":
+			if m.paneIndex == 0 {
+				m.move(-1)
+			} else {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		case "down", "j":
+			if m.paneIndex == 0 {
+				m.move(1)
+			} else {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		case "enter":
+			if m.paneIndex == 0 {
+				m.paneIndex = 1
+			}
+		case "r":
+			m.statusMsg = "Loading gists..."
+			m.details = map[string]*github.Gist{}
+			m.loading = map[string]bool{}
+			return m, m.loadGists("")
+		case "o":
+			if r, ok := m.selected(); ok {
+				return m, browser.OpenCmd(m.config, r.gist.GetHTMLURL())
+			}
+		case "y":
+			if r, ok := m.selected(); ok {
+				return m, m.copyPermalink(r.gist)
+			}
+		case "n":
+			return openGistForm(m)
+		case "e":
+			return m.edit()
+		case "f":
+			if r, ok := m.selected(); ok {
+				m.statusMsg = "Forking " + title(r.gist) + "..."
+				return m, m.fork(r.gist)
+			}
+		case "*":
+			if r, ok := m.selected(); ok {
+				return m, m.toggleStar(r.gist)
+			}
+		case "D":
+			if r, ok := m.selected(); ok {
+				m.confirm = &confirmation{
+					prompt: "Delete the gist " + title(r.gist) + "? (y/n)",
+					action: m.delete(r.gist),
+				}
+			}
+		default:
+			if m.paneIndex == 1 {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		}
+	case spinner.TickMsg:
+		m.spinner, cmd = m.spinner.Update(msg)
+	case gistsLoadedMsg:
+		m.status = statusReady
+		// Keep the report of the gist that was just created or forked.
+		if msg.selectID == "" {
+			m.statusMsg = ""
+		}
+		m.setRows(msg.gists, msg.selectID)
+	case gistLoadedMsg:
+		id := msg.id
+		delete(m.loading, id)
+		if msg.err != nil {
+			// Show the error until another row is selected, which retries.
+			if r, ok := m.selected(); ok && r.gist.GetID() == id {
+				m.shown = m.index
+				m.rightPane.SetContent("Could not load " + title(r.gist) + ": " + msg.err.Error())
+			}
+			break
+		}
+		m.details[id] = msg.gist
+		m.starred[id] = msg.starred
+		m.shown = -1
+	case gistSavedMsg:
+		return updateGistSaved(m, msg)
+	case gistEditedMsg:
+		return updateGistEdited(m, msg)
+	case gistStarredMsg:
+		m.starred[msg.id] = msg.starred
+		m.shown = -1
+		m.statusMsg = msg.status
+	case gistDeletedMsg:
+		m.removeGist(string(msg))
+		m.statusMsg = "Deleted the gist."
+	case statusMsg:
+		m.statusMsg = string(msg)
+	case browser.OpenedMsg:
+		m.statusMsg = msg.Status()
+	case browser.CopiedMsg:
+		m.statusMsg = msg.Status()
+	case errorMsg:
+		m.status = statusReady
+		m.statusMsg = msg.Error()
+	}
+	m.leftPane.Active = m.paneIndex == 0
+	m.rightPane.Active = m.paneIndex == 1
+	return m, common.BatchCommands(cmd, m.showSelected())
+}
+
+func (m Model) View() string {
+	title := common.ListTitleStyle().Render(m.user.GetLogin() + " · Gists")
+	if m.status == statusLoading {
+		return common.AppStyle().Render(title + "\n\n" + m.spinner.View() + " Loading gists...")
+	}
+	if m.form != nil {
+		return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, title, "", m.form.View()))
+	}
+
+	m.leftPane.Viewport.SetContent(m.rowsView())
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, m.leftPane.View(), m.rightPane.View())
+	statusBar := lipgloss.NewStyle().Foreground(common.GrayColor()).
+		Render("n new · e edit · f fork · * star · D delete · r refresh · o open in browser · y copy link · tab switch pane · esc back")
+	switch {
+	case m.describing != nil:
+		statusBar = m.input.View()
+	case m.confirm != nil:
+		statusBar = m.confirm.prompt
+	case m.statusMsg != "":
+		statusBar = m.statusMsg
+	}
+	return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, title, panes, statusBar))
+}
+
+func (m Model) rowsView() string {
+	if len(m.rows) == 0 {
+		return "No gists yet. Press n to create one."
+	}
+	width := uint(m.leftPane.Viewport.Width)
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	var lines []string
+	for i, r := range m.rows {
+		var line string
+		if r.file != "" {
+			line = "  " + r.file
+		} else {
+			line = title(r.gist)
+			if !r.gist.GetPublic() {
+				line += gray.Render(" secret")
+			}
+			if m.starred[r.gist.GetID()] {
+				line += gray.Render(" ★")
+			}
+		}
+		line = truncate.StringWithTail(line, width, "…")
+		if i == m.index {
+			line = common.PaneSelectedItemStyle().Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// setRows lists every gist followed by its files, keeping the selection on
+// the same row where possible.
+func (m *Model) setRows(gists []*github.Gist, selectID string) {
+	previous, _ := m.selected()
+	m.rows = nil
+	m.index = 0
+	for _, gist := range gists {
+		id := gist.GetID()
+		if id == selectID || (selectID == "" && previous.gist != nil && previous.file == "" && id == previous.gist.GetID()) {
+			m.index = len(m.rows)
+		}
+		m.rows = append(m.rows, row{gist: gist})
+		for _, file := range fileNames(gist) {
+			if selectID == "" && previous.gist != nil && id == previous.gist.GetID() && file == previous.file {
+				m.index = len(m.rows)
+			}
+			m.rows = append(m.rows, row{gist: gist, file: file})
+		}
+	}
+	m.shown = -1
+	m.move(0)
+}
+
+// removeGist drops a deleted gist and its files from the list.
+func (m *Model) removeGist(id string) {
+	var gists []*github.Gist
+	for _, r := range m.rows {
+		if r.file == "" && r.gist.GetID() != id {
+			gists = append(gists, r.gist)
+		}
+	}
+	delete(m.details, id)
+	m.setRows(gists, "")
+}
+
+func (m *Model) move(delta int) {
+	m.index += delta
+	if m.index >= len(m.rows) {
+		m.index = len(m.rows) - 1
+	}
+	if m.index < 0 {
+		m.index = 0
+	}
+	if m.index < m.leftPane.Viewport.YOffset {
+		m.leftPane.Viewport.YOffset = m.index
+	} else if height := m.leftPane.Viewport.Height; height > 0 && m.index >= m.leftPane.Viewport.YOffset+height {
+		m.leftPane.Viewport.YOffset = m.index - height + 1
+	}
+}
+
+func (m Model) selected() (row, bool) {
+	if m.index < 0 || m.index >= len(m.rows) {
+		return row{}, false
+	}
+	return m.rows[m.index], true
+}
+
+// showSelected puts the selected gist or file into the right pane, fetching
+// the gist first if its files haven't been loaded yet.
+func (m *Model) showSelected() tea.Cmd {
+	if m.status != statusReady || m.index == m.shown {
+		return nil
+	}
+	r, ok := m.selected()
+	if !ok {
+		m.shown = m.index
+		m.rightPane.SetContent("Press n to create your first gist.")
+		return nil
+	}
+	id := r.gist.GetID()
+	gist, loaded := m.details[id]
+	if !loaded {
+		if m.loading[id] {
+			return nil
+		}
+		m.loading[id] = true
+		m.rightPane.SetContent("Loading " + title(r.gist) + "...")
+		return m.loadGist(id)
+	}
+	m.shown = m.index
+	if r.file == "" {
+		m.rightPane.SetContent(m.describe(gist))
+		m.rightPane.Viewport.GotoTop()
+		return nil
+	}
+	content := fileContent(gist, r.file)
+	m.rightPane.SetDocument(content, common.Highlight(r.file, content))
+	return nil
+}
+
+// describe describes a gist and lists its files.
+func (m Model) describe(gist *github.Gist) string {
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	visibility := "public"
+	if !gist.GetPublic() {
+		visibility = "secret"
+	}
+	facts := []string{visibility, "by " + gist.GetOwner().GetLogin(), "updated " + common.RelativeTime(gist.GetUpdatedAt())}
+	if comments := gist.GetComments(); comments > 0 {
+		facts = append(facts, common.Plural(comments, "comment"))
+	}
+	if m.starred[gist.GetID()] {
+		facts = append(facts, "★ starred")
+	}
+	lines := []string{
+		lipgloss.NewStyle().Bold(true).Render(title(gist)),
+		gray.Render(strings.Join(facts, " · ")),
+		"",
+		"Files",
+	}
+	for _, name := range fileNames(gist) {
+		file := gist.Files[github.GistFilename(name)]
+		lines = append(lines, fmt.Sprintf("  %-40s %-14s %10s",
+			truncate.StringWithTail(name, 40, "…"),
+			file.GetLanguage(),
+			common.FormatBytes(int64(file.GetSize())),
+		))
+	}
+	lines = append(lines, "", gray.Render(gist.GetHTMLURL()), gray.Render("git clone "+gist.GetGitPullURL()))
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) loadGists(selectID string) tea.Cmd {
+	return func() tea.Msg {
+		gists, _, err := m.gh.Gists.List(context.Background(), "", &github.GistListOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+		})
+		if err != nil {
+			return errorMsg(err)
+		}
+		return gistsLoadedMsg{gists: gists, selectID: selectID}
+	}
+}
+
+// loadGist fetches a gist with the contents of its files.
+func (m Model) loadGist(id string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		gist, _, err := m.gh.Gists.Get(ctx, id)
+		if err != nil {
+			return gistLoadedMsg{id: id, err: err}
+		}
+		starred, _, _ := m.gh.Gists.IsStarred(ctx, id)
+		return gistLoadedMsg{id: id, gist: gist, starred: starred}
+	}
+}
+
+// copyPermalink copies the URL of the latest revision of gist.
+func (m Model) copyPermalink(gist *github.Gist) tea.Cmd {
+	return func() tea.Msg {
+		commits, _, err := m.gh.Gists.ListCommits(context.Background(), gist.GetID(), &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return statusMsg("Could not look up the revision of " + title(gist) + ": " + err.Error())
+		}
+		u := gist.GetHTMLURL()
+		if len(commits) > 0 {
+			u += "/" + commits[0].GetVersion()
+		}
+		return browser.CopiedMsg{URL: u, Err: browser.Copy(u)}
+	}
+}
+
+// title is the description of a gist, or else the name of its first file.
+func title(gist *github.Gist) string {
+	if description := strings.TrimSpace(gist.GetDescription()); description != "" {
+		return description
+	}
+	if names := fileNames(gist); len(names) > 0 {
+		return names[0]
+	}
+	return gist.GetID()
+}
+
+// fileNames returns the names of the files of a gist in the order GitHub
+// shows them.
+func fileNames(gist *github.Gist) []string {
+	var names []string
+	for name := range gist.Files {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fileContent returns the contents of a file of a gist, which are only
+// known once the gist itself was fetched.
+func fileContent(gist *github.Gist, name string) string {
+	file := gist.Files[github.GistFilename(name)]
+	return file.GetContent()
+}
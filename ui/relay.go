@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"reflect"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// relay hands what commands return to the model through a queue that
+// outlives the tea.Program. The program is stopped while an editor runs, and
+// drops the results of the commands that were still running; the relay keeps
+// them for the next program instead.
+type relay struct {
+	mu    sync.Mutex
+	queue []tea.Msg
+	// wake has a token whenever messages were queued since the model last
+	// took them.
+	wake chan struct{}
+}
+
+// relayMsg tells the model that messages are waiting in the relay.
+type relayMsg struct{}
+
+var (
+	// batchType is the type of the message tea.Batch commands return.
+	batchType  = reflect.TypeOf(tea.Batch(func() tea.Msg { return nil })())
+	teaPackage = reflect.TypeOf(tea.KeyMsg{}).PkgPath()
+)
+
+func newRelay() *relay {
+	return &relay{wake: make(chan struct{}, 1)}
+}
+
+// wrap makes cmd deliver what it returns through the relay.
+func (r *relay) wrap(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		r.run(cmd)
+		// The program hands nil to the model, which ignores it.
+		return nil
+	}
+}
+
+// run queues what cmd returns. The commands of a batch are run concurrently,
+// like the program runs them.
+func (r *relay) run(cmd tea.Cmd) {
+	msg := cmd()
+	if cmds, ok := batched(msg); ok {
+		for _, cmd := range cmds {
+			if cmd != nil {
+				go r.run(cmd)
+			}
+		}
+		return
+	}
+	if msg != nil {
+		r.push(msg)
+	}
+}
+
+func (r *relay) push(msg tea.Msg) {
+	r.mu.Lock()
+	r.queue = append(r.queue, msg)
+	r.mu.Unlock()
+	r.signal()
+}
+
+func (r *relay) signal() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take empties the queue.
+func (r *relay) take() []tea.Msg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	msgs := r.queue
+	r.queue = nil
+	return msgs
+}
+
+// requeue puts msgs back in front of the queue, for the next program.
+func (r *relay) requeue(msgs []tea.Msg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queue = append(append([]tea.Msg{}, msgs...), r.queue...)
+}
+
+func (r *relay) pending() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.queue) > 0
+}
+
+// listen waits for messages to be queued, until the program of the session
+// that is done stops.
+func (r *relay) listen(done <-chan struct{}) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case <-r.wake:
+		case <-done:
+			return nil
+		}
+		select {
+		case <-done:
+			// Leave the token to the listener of the next program.
+			r.signal()
+			return nil
+		default:
+			return relayMsg{}
+		}
+	}
+}
+
+// batched returns the commands of a message returned by tea.Batch.
+func batched(msg tea.Msg) ([]tea.Cmd, bool) {
+	if msg == nil || reflect.TypeOf(msg) != batchType {
+		return nil, false
+	}
+	v := reflect.ValueOf(msg)
+	cmds := make([]tea.Cmd, v.Len())
+	for i := range cmds {
+		cmds[i], _ = v.Index(i).Interface().(tea.Cmd)
+	}
+	return cmds, true
+}
+
+// internal reports whether msg is one of tea's own messages, like the one
+// tea.Quit returns, which only the program can act on.
+func internal(msg tea.Msg) bool {
+	t := reflect.TypeOf(msg)
+	if t.PkgPath() != teaPackage {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(t.Name())
+	return unicode.IsLower(first)
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"sort"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRelayKeepsMessagesAcrossPrograms(t *testing.T) {
+	r := newRelay()
+	first := make(chan struct{})
+	close(first)
+	if msg := r.listen(first)(); msg != nil {
+		t.Fatalf("stopped program got %v", msg)
+	}
+
+	cmd := tea.Batch(
+		func() tea.Msg { return "a" },
+		tea.Batch(func() tea.Msg { return "b" }, nil),
+		func() tea.Msg { return nil },
+	)
+	if msg := r.wrap(cmd)(); msg != nil {
+		t.Fatalf("wrapped command returned %v", msg)
+	}
+
+	second := make(chan struct{})
+	var got []string
+	for len(got) < 2 {
+		if _, ok := r.listen(second)().(relayMsg); !ok {
+			t.Fatal("listener didn't report the queued messages")
+		}
+		for _, msg := range r.take() {
+			got = append(got, msg.(string))
+		}
+	}
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("got %q, want the messages of every command in the batch", got)
+	}
+}
+
+func TestInternal(t *testing.T) {
+	if !internal(tea.Quit()) {
+		t.Error("the message of tea.Quit isn't internal")
+	}
+	if internal(tea.KeyMsg{}) || internal(relayMsg{}) {
+		t.Error("exported and own messages are internal")
+	}
+}
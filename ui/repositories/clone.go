@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/config"
+	"ghtui/ghtui/git"
+	"ghtui/ghtui/ui/common"
+)
+
+// cloneLogLines is how many lines of git output the clone pane shows.
+const cloneLogLines = 5
+
+type cloneEvent struct {
+	line string
+	done bool
+	err  error
+}
+
+type cloneProgressMsg struct {
+	name    string
+	dir     string
+	event   cloneEvent
+	updates <-chan cloneEvent
+}
+
+type cloneModel struct {
+	active bool
+	name   string
+	dir    string
+	log    []string
+	result string
+}
+
+// cloneURL picks the HTTPS or SSH URL of repo according to the config.
+func cloneURL(cfg *config.Config, repo *github.Repository) string {
+	if cfg.Clone.Protocol == config.ProtocolSSH {
+		return repo.GetSSHURL()
+	}
+	return repo.GetCloneURL()
+}
+
+// cloneDir returns where repo is cloned to in the configured workspace.
+func cloneDir(cfg *config.Config, repo *github.Repository) (string, error) {
+	return cfg.Clone.Dir(repo.GetOwner().GetLogin(), repo.GetName())
+}
+
+func startClone(m Model, repo *github.Repository) (Model, tea.Cmd) {
+	if m.clone.active {
+		m.clone.result = "A clone is already running."
+		return m, nil
+	}
+	dir, err := cloneDir(m.config, repo)
+	if err != nil {
+		m.clone.result = err.Error()
+		return m, nil
+	}
+	if git.IsRepository(dir) {
+		m.clone.result = repo.GetFullName() + " is already cloned to " + dir
+		return m, nil
+	}
+
+	url := cloneURL(m.config, repo)
+	updates := make(chan cloneEvent)
+	go func() {
+		err := git.Clone(url, dir, func(line string) {
+			updates <- cloneEvent{line: line}
+		})
+		updates <- cloneEvent{done: true, err: err}
+	}()
+
+	m.clone = cloneModel{active: true, name: repo.GetFullName(), dir: dir}
+	return m, waitForClone(repo.GetFullName(), dir, updates)
+}
+
+func waitForClone(name string, dir string, updates <-chan cloneEvent) tea.Cmd {
+	return func() tea.Msg {
+		return cloneProgressMsg{name: name, dir: dir, event: <-updates, updates: updates}
+	}
+}
+
+func updateClone(m Model, msg cloneProgressMsg) (Model, tea.Cmd) {
+	if !msg.event.done {
+		m.clone.log = appendCloneLog(m.clone.log, msg.event.line)
+		return m, waitForClone(msg.name, msg.dir, msg.updates)
+	}
+
+	m.clone.active = false
+	if msg.event.err != nil {
+		m.clone.result = "Could not clone " + msg.name + ": " + msg.event.err.Error()
+		return m, nil
+	}
+	m.clone.result = "Cloned " + msg.name + " to " + msg.dir
+	return markCloned(m)
+}
+
+// appendCloneLog adds a line of git output. Progress counters are redrawn by
+// git, so a line updating the same counter replaces the previous one.
+func appendCloneLog(log []string, line string) []string {
+	if n := len(log); n > 0 {
+		if prefix, _, ok := cutLast(log[n-1], ":"); ok && strings.HasPrefix(line, prefix+":") {
+			log[n-1] = line
+			return log
+		}
+	}
+	log = append(log, line)
+	if len(log) > cloneLogLines {
+		log = log[len(log)-cloneLogLines:]
+	}
+	return log
+}
+
+func cutLast(s string, sep string) (string, string, bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// markCloned refreshes the cloned marker of every loaded repository.
+func markCloned(m Model) (Model, tea.Cmd) {
+	for i, listItem := range m.items {
+		it := listItem.(item)
+		it.cloned = m.isCloned(it.repo)
+		m.items[i] = it
+	}
+	return m.refreshItems()
+}
+
+func (m Model) isCloned(repo *github.Repository) bool {
+	dir, err := cloneDir(m.config, repo)
+	return err == nil && git.IsRepository(dir)
+}
+
+func (c cloneModel) View(width int) string {
+	var lines []string
+	if c.active {
+		lines = append(lines, "Cloning "+c.name+" into "+c.dir)
+		lines = append(lines, c.log...)
+	} else if c.result != "" {
+		lines = append(lines, c.result)
+	} else {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(common.GrayColor()).
+		Width(width - 2).
+		Render(strings.Join(lines, "\n"))
+}
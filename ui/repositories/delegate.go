@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// itemDelegate renders repositories like the default delegate, with an
+// extra line of metadata below the description.
+type itemDelegate struct {
+	list.DefaultDelegate
+}
+
+func newItemDelegate() itemDelegate {
+	return itemDelegate{DefaultDelegate: list.NewDefaultDelegate()}
+}
+
+func (d itemDelegate) Height() int {
+	return d.DefaultDelegate.Height() + 1
+}
+
+func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	i, ok := listItem.(item)
+	if !ok {
+		return
+	}
+
+	var b bytes.Buffer
+	d.DefaultDelegate.Render(&b, m, index, listItem)
+
+	style := d.Styles.NormalDesc
+	if m.FilterState() == list.Filtering && m.FilterValue() == "" {
+		style = d.Styles.DimmedDesc
+	} else if index == m.Index() && m.FilterState() != list.Filtering {
+		style = d.Styles.SelectedDesc
+	}
+	meta := i.metadata()
+	if width := m.Width() - style.GetPaddingLeft() - style.GetPaddingRight(); width > 0 {
+		meta = truncate.StringWithTail(meta, uint(width), "…")
+	}
+	fmt.Fprintf(w, "%s\n%s", b.String(), style.Render(meta))
+}
+
+// badges are shown after the repository name. They are plain text, as the
+// title gets restyled rune by rune to highlight filter matches.
+func (i item) badges() string {
+	repo := i.repo
+	if repo == nil {
+		return ""
+	}
+	var badges []string
+	if visibility := repo.GetVisibility(); visibility != "" && visibility != "public" {
+		badges = append(badges, visibility)
+	} else if repo.GetPrivate() {
+		badges = append(badges, "private")
+	}
+	if repo.GetFork() {
+		badges = append(badges, "fork")
+	}
+	if repo.GetArchived() {
+		badges = append(badges, "archived")
+	}
+	if i.social.starred {
+		badges = append(badges, "starred")
+	}
+	switch i.social.watch {
+	case watchAll:
+		badges = append(badges, "watching")
+	case watchIgnore:
+		badges = append(badges, "ignored")
+	}
+	if len(badges) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(badges, " · ") + "]"
+}
+
+// metadata summarises the stars, forks, language and last push.
+func (i item) metadata() string {
+	repo := i.repo
+	if repo == nil {
+		return ""
+	}
+	parts := []string{
+		fmt.Sprintf("★ %d", repo.GetStargazersCount()),
+		fmt.Sprintf("⑂ %d", repo.GetForksCount()),
+	}
+	if language := repo.GetLanguage(); language != "" {
+		parts = append(parts, language)
+	}
+	parts = append(parts, "pushed "+common.RelativeTime(repo.GetPushedAt().Time))
+	if !i.starredAt.IsZero() {
+		parts = append(parts, "starred "+common.RelativeTime(i.starredAt))
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,304 @@
+package repositories
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/config"
+	"ghtui/ghtui/ui/common"
+)
+
+const (
+	filterAffiliation = iota
+	filterVisibility
+	filterType
+	filterArchived
+	filterLanguage
+	filterRows
+)
+
+var (
+	affiliationOptions = []string{"owner", "member", "collaborator"}
+	visibilityOptions  = []string{"public", "private", "internal"}
+	typeOptions        = []string{"", "sources", "forks"}
+	archivedOptions    = []string{"", "hide", "only"}
+)
+
+// filterMenu edits the repository filter stored in the config.
+type filterMenu struct {
+	open   bool
+	row    int
+	column int
+}
+
+// affiliationQueries are the affiliations the repository list is queried
+// for, keyed by the filter option they stand for.
+var affiliationQueries = map[string]string{
+	"owner":        "owner",
+	"member":       "organization_member",
+	"collaborator": "collaborator",
+}
+
+func visibility(repo *github.Repository) string {
+	if v := repo.GetVisibility(); v != "" {
+		return v
+	}
+	if repo.GetPrivate() {
+		return "private"
+	}
+	return "public"
+}
+
+// matches reports whether repo passes every part of the filter.
+// Starred and watched repositories aren't listed by affiliation, so the
+// affiliations only filter the user's own list.
+func (m Model) matches(f config.RepositoryFilter, i item) bool {
+	repo := i.repo
+	if len(f.Affiliations) > 0 && i.affiliations != nil && !containsAny(f.Affiliations, i.affiliations) {
+		return false
+	}
+	if len(f.Visibilities) > 0 && !contains(f.Visibilities, visibility(repo)) {
+		return false
+	}
+	switch f.Type {
+	case "sources":
+		if repo.GetFork() {
+			return false
+		}
+	case "forks":
+		if !repo.GetFork() {
+			return false
+		}
+	}
+	switch f.Archived {
+	case "hide":
+		if repo.GetArchived() {
+			return false
+		}
+	case "only":
+		if !repo.GetArchived() {
+			return false
+		}
+	}
+	return f.Language == "" || strings.EqualFold(f.Language, repo.GetLanguage())
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether any of wanted is in values.
+func containsAny(values []string, wanted []string) bool {
+	for _, v := range wanted {
+		if contains(values, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// activeFilters counts the parts of the filter that are set.
+func activeFilters(f config.RepositoryFilter) int {
+	n := 0
+	for _, set := range []bool{len(f.Affiliations) > 0, len(f.Visibilities) > 0, f.Type != "", f.Archived != "", f.Language != ""} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// refreshItems filters and sorts every loaded repository into the list. The
+// list's own text filter is applied on top by the list itself.
+func (m Model) refreshItems() (Model, tea.Cmd) {
+	var items []list.Item
+	for _, listItem := range m.items {
+		if m.matches(m.config.RepositoryFilter, listItem.(item)) {
+			items = append(items, listItem)
+		}
+	}
+	sortItems(items, m.sort)
+	m.list.Title = m.title()
+	return m, m.list.SetItems(items)
+}
+
+// languages lists the primary languages of the loaded repositories.
+func (m Model) languages() []string {
+	seen := map[string]bool{}
+	languages := []string{""}
+	for _, listItem := range m.items {
+		language := listItem.(item).repo.GetLanguage()
+		if language != "" && !seen[language] {
+			seen[language] = true
+			languages = append(languages, language)
+		}
+	}
+	sort.Strings(languages[1:])
+	return languages
+}
+
+func (m Model) filterOptions(row int) []string {
+	switch row {
+	case filterAffiliation:
+		return affiliationOptions
+	case filterVisibility:
+		return visibilityOptions
+	case filterType:
+		return typeOptions
+	case filterArchived:
+		return archivedOptions
+	default:
+		return m.languages()
+	}
+}
+
+func updateFilterMenu(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	menu := &m.filterMenu
+	options := m.filterOptions(menu.row)
+	f := &m.config.RepositoryFilter
+	switch msg.String() {
+	case "esc", "enter", "f":
+		menu.open = false
+		if err := m.config.Save(); err != nil {
+			m.errMsg = "Could not save the filter: " + err.Error()
+		}
+		return m, nil
+	case "up", "k":
+		if menu.row > 0 {
+			menu.row--
+			menu.column = 0
+		}
+	case "down", "j":
+		if menu.row < filterRows-1 {
+			menu.row++
+			menu.column = 0
+		}
+	case "left", "h":
+		if menu.column > 0 {
+			menu.column--
+		}
+	case "right", "l":
+		if menu.column < len(options)-1 {
+			menu.column++
+		}
+	case " ", "x":
+		option := options[menu.column]
+		switch menu.row {
+		case filterAffiliation:
+			f.Affiliations = toggle(f.Affiliations, affiliationOptions, option)
+		case filterVisibility:
+			f.Visibilities = toggle(f.Visibilities, visibilityOptions, option)
+		case filterType:
+			f.Type = option
+		case filterArchived:
+			f.Archived = option
+		case filterLanguage:
+			f.Language = option
+		}
+		return m.refreshItems()
+	case "backspace":
+		m.config.RepositoryFilter = config.RepositoryFilter{}
+		return m.refreshItems()
+	}
+	return m, nil
+}
+
+// toggle flips option in a multiple choice filter, where no selection at all
+// means every option. The last selected option can't be turned off.
+func toggle(selected []string, options []string, option string) []string {
+	if len(selected) == 0 {
+		selected = append([]string(nil), options...)
+	}
+	var result []string
+	found := false
+	for _, s := range selected {
+		if s == option {
+			found = true
+			continue
+		}
+		result = append(result, s)
+	}
+	if !found {
+		result = append(result, option)
+	}
+	if len(result) == 0 {
+		return selected
+	}
+	if len(result) == len(options) {
+		return nil
+	}
+	return result
+}
+
+func (m Model) filterMenuView() string {
+	f := m.config.RepositoryFilter
+	labels := []string{"Affiliation", "Visibility", "Type", "Archived", "Language"}
+	var lines []string
+	for row := 0; row < filterRows; row++ {
+		var cells []string
+		for column, option := range m.filterOptions(row) {
+			var checked bool
+			var cell string
+			switch row {
+			case filterAffiliation:
+				checked = len(f.Affiliations) == 0 || contains(f.Affiliations, option)
+			case filterVisibility:
+				checked = len(f.Visibilities) == 0 || contains(f.Visibilities, option)
+			case filterType:
+				checked = f.Type == option
+			case filterArchived:
+				checked = f.Archived == option
+			case filterLanguage:
+				checked = f.Language == option
+			}
+			label := option
+			if label == "" {
+				label = "all"
+			}
+			if row == filterAffiliation || row == filterVisibility {
+				cell = "[ ] " + label
+				if checked {
+					cell = "[x] " + label
+				}
+			} else {
+				cell = "( ) " + label
+				if checked {
+					cell = "(•) " + label
+				}
+			}
+			if row == m.filterMenu.row && column == m.filterMenu.column {
+				cell = common.PaneSelectedItemStyle().Render(cell)
+			}
+			cells = append(cells, cell)
+		}
+		lines = append(lines, lipgloss.NewStyle().Width(13).Render(labels[row]+":")+strings.Join(cells, "  "))
+	}
+	lines = append(lines, "", "space: toggle  backspace: clear  enter: done")
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(common.GrayColor()).
+		Render(strings.Join(lines, "\n"))
+}
+
+func (m Model) filterSummary() string {
+	n := activeFilters(m.config.RepositoryFilter)
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return ", 1 filter"
+	default:
+		return ", " + strconv.Itoa(n) + " filters"
+	}
+}
@@ -0,0 +1,357 @@
+package overview
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// Section is a part of the repository the overview links into.
+type Section int
+
+const (
+	SectionNone Section = iota
+	SectionFiles
+	SectionIssues
+	SectionPullRequests
+	SectionActions
+	SectionReleases
+)
+
+// Action is something to do with the repository that the parent carries out.
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionStar
+	ActionWatch
+	ActionFork
+	ActionOpen
+	ActionCopyPermalink
+)
+
+type status int
+
+const (
+	statusLoading status = iota
+	statusReady
+)
+
+// barWidth is the width of the languages bar chart.
+const barWidth = 50
+
+// languageColors are cycled through for the languages bar chart.
+var languageColors = []string{"#99c1b9", "#8e7dbe", "#d88c9a", "#f2d0a9", "#f1e3d3", "#505050"}
+
+type overviewLoadedMsg struct {
+	repository   *github.Repository
+	languages    map[string]int
+	contributors int
+	release      *github.RepositoryRelease
+	pullRequests int
+	readme       string
+}
+type overviewErrorMsg error
+
+type Model struct {
+	Done bool
+	// Open is set to the section the user asked to open. The parent resets it
+	// once handled.
+	Open Section
+	// Action is set to the action the user asked for. The parent resets it
+	// once handled.
+	Action Action
+
+	repository *github.Repository
+	gh         *github.Client
+	spinner    spinner.Model
+	status     status
+	viewport   viewport.Model
+	data       overviewLoadedMsg
+	errMsg     string
+	statusMsg  string
+	footer     string
+	starred    bool
+	watching   string
+}
+
+func NewModel(repository *github.Repository, gh *github.Client) Model {
+	width, height := common.ScreenSize()
+	top, right, bottom, left := common.AppStyle().GetPadding()
+	return Model{
+		repository: repository,
+		gh:         gh,
+		spinner:    common.NewSpinnerModel(),
+		status:     statusLoading,
+		viewport: viewport.Model{
+			Width:  width - left - right,
+			Height: height - top - bottom - 2,
+		},
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.load, spinner.Tick)
+}
+
+// Repository returns the repository, with every detail once loaded.
+func (m Model) Repository() *github.Repository {
+	return m.repository
+}
+
+// SetStatus shows a message below the overview, e.g. the outcome of an action
+// run by the parent.
+func (m *Model) SetStatus(s string) {
+	m.statusMsg = s
+}
+
+// SetSocial shows whether the user starred and watches the repository.
+func (m *Model) SetSocial(starred bool, watching string) {
+	m.starred = starred
+	m.watching = watching
+	if m.status == statusReady {
+		m.viewport.SetContent(m.content())
+	}
+}
+
+// SetFooter replaces the key help below the overview, e.g. with a prompt of
+// the parent. An empty footer restores the help.
+func (m *Model) SetFooter(s string) {
+	m.footer = s
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.Done = true
+		case "f", "enter":
+			m.Open = SectionFiles
+		case "i":
+			m.Open = SectionIssues
+		case "p":
+			m.Open = SectionPullRequests
+		case "a":
+			m.Open = SectionActions
+		case "R":
+			m.Open = SectionReleases
+		case "*":
+			m.Action = ActionStar
+		case "w":
+			m.Action = ActionWatch
+		case "F":
+			m.Action = ActionFork
+		case "o":
+			m.Action = ActionOpen
+		case "y":
+			m.Action = ActionCopyPermalink
+		default:
+			m.viewport, cmd = m.viewport.Update(msg)
+		}
+	case tea.WindowSizeMsg:
+		top, right, bottom, left := common.AppStyle().GetPadding()
+		m.viewport.Width = msg.Width - left - right
+		m.viewport.Height = msg.Height - top - bottom - 2
+		if m.status == statusReady {
+			m.viewport.SetContent(m.content())
+		}
+	case spinner.TickMsg:
+		m.spinner, cmd = m.spinner.Update(msg)
+	case overviewLoadedMsg:
+		m.status = statusReady
+		m.data = msg
+		m.repository = msg.repository
+		m.viewport.SetContent(m.content())
+	case overviewErrorMsg:
+		m.status = statusReady
+		m.errMsg = msg.Error()
+	}
+	return m, cmd
+}
+
+func (m Model) View() string {
+	title := common.ListTitleStyle().Render(m.repository.GetFullName())
+	switch m.status {
+	case statusLoading:
+		return common.AppStyle().Render(title + "\n\n" + m.spinner.View() + " Loading overview...")
+	}
+	s := m.viewport.View()
+	if m.errMsg != "" {
+		s = common.ErrorStyle().Render(m.errMsg)
+	}
+	if m.statusMsg != "" {
+		s = lipgloss.JoinVertical(lipgloss.Left, s, common.ListStatusMessageStyle().Render(m.statusMsg))
+	}
+	help := m.footer
+	if help == "" {
+		help = lipgloss.NewStyle().Foreground(common.GrayColor()).
+			Render("f files · i issues · p pull requests · a actions · R releases · * star · w watch · F fork · o open in browser · y copy permalink · esc back")
+	}
+	return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, title, s, help))
+}
+
+// load fetches every part of the overview concurrently.
+func (m Model) load() tea.Msg {
+	ctx := context.Background()
+	owner, name := m.repository.GetOwner().GetLogin(), m.repository.GetName()
+
+	repo, _, err := m.gh.Repositories.Get(ctx, owner, name)
+	if err != nil {
+		return overviewErrorMsg(err)
+	}
+	msg := overviewLoadedMsg{repository: repo}
+
+	var wg sync.WaitGroup
+	wg.Add(5)
+	go func() {
+		defer wg.Done()
+		msg.languages, _, _ = m.gh.Repositories.ListLanguages(ctx, owner, name)
+	}()
+	go func() {
+		defer wg.Done()
+		// With one item per page, the last page number is the total count.
+		contributors, resp, err := m.gh.Repositories.ListContributors(ctx, owner, name, &github.ListContributorsOptions{
+			Anon:        "true",
+			ListOptions: github.ListOptions{PerPage: 1},
+		})
+		if err == nil {
+			msg.contributors = count(len(contributors), resp)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		release, resp, err := m.gh.Repositories.GetLatestRelease(ctx, owner, name)
+		if err == nil || (resp != nil && resp.StatusCode == http.StatusNotFound) {
+			msg.release = release
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		pulls, resp, err := m.gh.PullRequests.List(ctx, owner, name, &github.PullRequestListOptions{
+			State:       "open",
+			ListOptions: github.ListOptions{PerPage: 1},
+		})
+		if err == nil {
+			msg.pullRequests = count(len(pulls), resp)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		readme, _, err := m.gh.Repositories.GetReadme(ctx, owner, name, nil)
+		if err == nil {
+			msg.readme, _ = readme.GetContent()
+		}
+	}()
+	wg.Wait()
+	return msg
+}
+
+func (m Model) starredLabel() string {
+	if m.starred {
+		return " (starred)"
+	}
+	return ""
+}
+
+// count returns the total number of items of a list fetched one per page.
+func count(items int, resp *github.Response) int {
+	if resp != nil && resp.LastPage > 0 {
+		return resp.LastPage
+	}
+	return items
+}
+
+func (m Model) content() string {
+	repo := m.repository
+	data := m.data
+	var sections []string
+
+	if description := repo.GetDescription(); description != "" {
+		sections = append(sections, description)
+	}
+	if len(repo.Topics) > 0 {
+		var topics []string
+		for _, topic := range repo.Topics {
+			topics = append(topics, common.PaneSelectedItemStyle().Render(" "+topic+" "))
+		}
+		sections = append(sections, strings.Join(topics, " "))
+	}
+
+	var facts []string
+	if homepage := repo.GetHomepage(); homepage != "" {
+		facts = append(facts, "Homepage:     "+homepage)
+	}
+	if license := repo.GetLicense(); license != nil {
+		facts = append(facts, "License:      "+license.GetName())
+	}
+	facts = append(facts,
+		fmt.Sprintf("Stars:        %d%s", repo.GetStargazersCount(), m.starredLabel()),
+		fmt.Sprintf("Forks:        %d", repo.GetForksCount()),
+		fmt.Sprintf("Contributors: %d", data.contributors),
+		// The open issues count of a repository includes pull requests.
+		fmt.Sprintf("Issues:       %d open", repo.GetOpenIssuesCount()-data.pullRequests),
+		fmt.Sprintf("Pulls:        %d open", data.pullRequests),
+	)
+	if m.watching != "" {
+		facts = append(facts, "Watching:     "+m.watching)
+	}
+	if release := data.release; release != nil {
+		facts = append(facts, fmt.Sprintf("Release:      %s (%s)", release.GetTagName(), common.RelativeTime(release.GetPublishedAt().Time)))
+	} else {
+		facts = append(facts, "Release:      none")
+	}
+	sections = append(sections, strings.Join(facts, "\n"))
+
+	if chart := languagesChart(data.languages); chart != "" {
+		sections = append(sections, chart)
+	}
+	if data.readme != "" {
+		sections = append(sections, common.RenderMarkdown(data.readme, m.viewport.Width-2))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// languagesChart renders the share of each language as a stacked bar with a
+// legend below it.
+func languagesChart(languages map[string]int) string {
+	total := 0
+	names := make([]string, 0, len(languages))
+	for name, bytes := range languages {
+		total += bytes
+		names = append(names, name)
+	}
+	if total == 0 {
+		return ""
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return languages[names[i]] > languages[names[j]]
+	})
+
+	var bar, legend strings.Builder
+	used := 0
+	for i, name := range names {
+		color := lipgloss.Color(languageColors[i%len(languageColors)])
+		width := languages[name] * barWidth / total
+		if i == len(names)-1 {
+			width = barWidth - used
+		}
+		used += width
+		bar.WriteString(lipgloss.NewStyle().Foreground(color).Render(strings.Repeat("█", width)))
+		legend.WriteString(lipgloss.NewStyle().Foreground(color).Render("●"))
+		legend.WriteString(fmt.Sprintf(" %s %.1f%%  ", name, float64(languages[name])*100/float64(total)))
+	}
+	return bar.String() + "\n" + legend.String()
+}
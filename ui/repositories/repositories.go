@@ -3,15 +3,21 @@ package repositories
 import (
 	"context"
 	"fmt"
-	"log"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/google/go-github/v39/github"
 
+	"ghtui/ghtui/browser"
+	"ghtui/ghtui/config"
 	"ghtui/ghtui/ui/common"
+	"ghtui/ghtui/ui/gists"
+	"ghtui/ghtui/ui/repositories/overview"
 	"ghtui/ghtui/ui/repositories/repository"
 )
 
@@ -21,22 +27,59 @@ const (
 	statusInit status = iota
 	statusLoading
 	statusReady
+	statusOverview
 	statusRepositorySelected
+	statusGists
 )
 
 type repositoriesLoadedMsg struct {
-	repos []*github.Repository
-	items []list.Item
+	source source
+	repos  []*github.Repository
+	items  []list.Item
+	// err is set when the repositories could not all be listed; repos holds
+	// the ones that were.
+	err error
 }
 type repositorySelectedMsg item
+type targetLoadedMsg struct {
+	repo *github.Repository
+	ref  string
+}
+type targetErrorMsg error
+type statusMsg string
+
+// Target is a repository to open directly, skipping the repository list.
+// An empty Ref opens the default branch.
+type Target struct {
+	Owner string
+	Name  string
+	Ref   string
+}
 type item struct {
 	name        string
 	description string
+	repo        *github.Repository
+	cloned      bool
+	social      social
+	starredAt   time.Time
+	// affiliations are the filter options the repository was listed for,
+	// or nil when it wasn't listed by affiliation.
+	affiliations []string
 }
 
 type listKeyMap struct {
 	toggleHelpMenu   key.Binding
 	selectRepository key.Binding
+	clone            key.Binding
+	openInBrowser    key.Binding
+	copyPermalink    key.Binding
+	sort             key.Binding
+	filter           key.Binding
+	star             key.Binding
+	watch            key.Binding
+	fork             key.Binding
+	switchSource     key.Binding
+	gists            key.Binding
 }
 
 type info struct {
@@ -53,10 +96,33 @@ type Model struct {
 	user       *github.User
 	spinner    spinner.Model
 	repos      []*github.Repository
+	overview   overview.Model
 	repository repository.Model
+	config     *config.Config
+	clone      cloneModel
+	target     *Target
+	errMsg     string
+	statusMsg  string
+	sort       sortOrder
+	source     source
+	items      []list.Item
+	filterMenu filterMenu
+	watchMenu  watchMenu
+	forkPrompt forkPrompt
+	gists      gists.Model
+	// socials are the repositories the user starred and watches, loaded in
+	// the background after the first list. They are nil until then.
+	socials        map[string]social
+	socialsLoading bool
 }
 
-func (i item) Title() string       { return i.name }
+func (i item) Title() string {
+	title := i.name + i.badges()
+	if i.cloned {
+		title += " ✓"
+	}
+	return title
+}
 func (i item) Description() string { return i.description }
 func (i item) FilterValue() string { return i.name }
 
@@ -133,19 +199,71 @@ func newListKeyMap() *listKeyMap {
 			key.WithKeys("enter"),
 			key.WithHelp("enter", "select repo"),
 		),
+		clone: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "clone repo"),
+		),
+		openInBrowser: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open in browser"),
+		),
+		copyPermalink: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy permalink"),
+		),
+		sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "change sort order"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "filter repos"),
+		),
+		star: key.NewBinding(
+			key.WithKeys("*"),
+			key.WithHelp("*", "star/unstar"),
+		),
+		watch: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "watch"),
+		),
+		fork: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "fork"),
+		),
+		switchSource: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "mine/starred/watched"),
+		),
+		gists: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "gists"),
+		),
 	}
 }
 
-func NewModel(user *github.User, gh *github.Client) Model {
+func NewModel(user *github.User, gh *github.Client, cfg *config.Config) Model {
 	return Model{
 		gh:      gh,
 		user:    user,
 		spinner: common.NewSpinnerModel(),
 		status:  statusInit,
+		config:  cfg,
 	}
 }
 
+// NewModelWithTarget opens target straight away. The repository list is only
+// loaded once the user leaves it.
+func NewModelWithTarget(user *github.User, gh *github.Client, cfg *config.Config, target *Target) Model {
+	m := NewModel(user, gh, cfg)
+	m.target = target
+	return m
+}
+
 func (m Model) Init() tea.Cmd {
+	if m.target != nil {
+		return tea.Batch(m.loadTarget, spinner.Tick)
+	}
 	return tea.Batch(m.loadRepositories, spinner.Tick)
 }
 
@@ -156,22 +274,85 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		topGap, rightGap, bottomGap, leftGap := common.AppStyle().GetPadding()
 		m.list.SetSize(msg.Width-leftGap-rightGap, msg.Height-topGap-bottomGap)
 	case tea.KeyMsg:
-		if m.status == statusReady {
+		if m.status == statusReady || m.status == statusOverview {
+			if m.watchMenu.open {
+				return updateWatchMenu(m, msg)
+			}
+			if m.forkPrompt.open {
+				return updateForkPrompt(m, msg)
+			}
+		}
+		if m.status == statusReady && m.filterMenu.open {
+			return updateFilterMenu(m, msg)
+		}
+		if m.status == statusReady && m.list.FilterState() != list.Filtering {
 			switch {
 			case key.Matches(msg, m.keys.toggleHelpMenu):
 				m.list.SetShowHelp(!m.list.ShowHelp())
 				return m, nil
 			case key.Matches(msg, m.keys.selectRepository):
-				selectedItem := m.list.VisibleItems()[m.list.Index()].(item)
-				return m, common.Cmd(repositorySelectedMsg(selectedItem))
+				if selectedItem, ok := m.list.SelectedItem().(item); ok {
+					return m, common.Cmd(repositorySelectedMsg(selectedItem))
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.clone):
+				if selectedItem, ok := m.list.SelectedItem().(item); ok {
+					return startClone(m, selectedItem.repo)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.openInBrowser):
+				if selectedItem, ok := m.list.SelectedItem().(item); ok {
+					return m, m.openInBrowser(selectedItem.repo)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.sort):
+				m.sort = m.sort.next(m.source)
+				return m.refreshItems()
+			case key.Matches(msg, m.keys.switchSource):
+				m.source = m.source.next()
+				if m.sort == sortByStarred && m.source != sourceStarred {
+					m.sort = sortByName
+				}
+				m.status = statusInit
+				return m, tea.Batch(m.loadRepositories, spinner.Tick)
+			case key.Matches(msg, m.keys.filter):
+				m.filterMenu.open = true
+				return m, nil
+			case key.Matches(msg, m.keys.copyPermalink):
+				if selectedItem, ok := m.list.SelectedItem().(item); ok {
+					return m, m.copyPermalink(selectedItem.repo)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.star):
+				if selectedItem, ok := m.list.SelectedItem().(item); ok {
+					return toggleStar(m, selectedItem.repo)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.watch):
+				if selectedItem, ok := m.list.SelectedItem().(item); ok {
+					return openWatchMenu(m, selectedItem.repo)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.gists):
+				m.status = statusGists
+				m.gists = gists.NewModel(m.user, m.gh, m.config)
+				return m, m.gists.Init()
+			case key.Matches(msg, m.keys.fork):
+				if selectedItem, ok := m.list.SelectedItem().(item); ok {
+					return openForkPrompt(m, selectedItem.repo)
+				}
+				return m, nil
 			}
 		}
 	case spinner.TickMsg:
 		m.spinner, cmd = m.spinner.Update(msg)
 	case repositoriesLoadedMsg:
+		if msg.source != m.source {
+			// The user switched sources while this one was loading.
+			return m, nil
+		}
 		listKeys := newListKeyMap()
-		repoList := list.NewModel(msg.items, list.NewDefaultDelegate(), 0, 0)
-		repoList.Title = *m.user.Login + " Repositories"
+		repoList := list.NewModel(nil, newItemDelegate(), 0, 0)
 		repoList.Styles.Title = common.ListTitleStyle()
 		repoList.ShowPagination()
 		topGap, rightGap, bottomGap, leftGap := common.AppStyle().GetPadding()
@@ -180,24 +361,82 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		repoList.AdditionalFullHelpKeys = func() []key.Binding {
 			return []key.Binding{
 				listKeys.toggleHelpMenu,
+				listKeys.clone,
+				listKeys.openInBrowser,
+				listKeys.copyPermalink,
+				listKeys.sort,
+				listKeys.filter,
+				listKeys.star,
+				listKeys.watch,
+				listKeys.fork,
+				listKeys.switchSource,
+				listKeys.gists,
 			}
 		}
 		m.repos = msg.repos
+		m.items = msg.items
 		m.keys = listKeys
 		m.list = repoList
 		m.status = statusReady
-		return m, cmd
-	case repositorySelectedMsg:
-		m.status = statusRepositorySelected
-		var repo *github.Repository
-		for _, r := range m.repos {
-			if *r.Name == msg.name {
-				repo = r
-				break
+		if msg.err != nil {
+			what := "repositories"
+			if msg.source != sourceMine {
+				what = strings.ToLower(msg.source.String()) + " " + what
 			}
+			m.statusMsg = "Could not load " + what + ": " + msg.err.Error()
+		}
+		var loadSocials tea.Cmd
+		if m.socials == nil && !m.socialsLoading {
+			m.socialsLoading = true
+			loadSocials = m.loadSocials
 		}
-		m.repository = repository.NewModel(m.user, repo, m.gh)
+		m, cmd = m.refreshItems()
+		return m, tea.Batch(cmd, loadSocials)
+	case repositorySelectedMsg:
+		m.status = statusOverview
+		m.overview = overview.NewModel(msg.repo, m.gh)
+		m.overview.SetSocial(msg.social.starred, string(msg.social.watch))
+		return m, tea.Batch(m.overview.Init(), m.loadSubscription(msg.repo))
+	case targetLoadedMsg:
+		m.status = statusRepositorySelected
+		m.repository = repository.NewModelAtRef(m.user, msg.repo, m.gh, m.config, msg.ref)
 		return m, m.repository.Init()
+	case targetErrorMsg:
+		m.errMsg = "Could not open " + m.target.Owner + "/" + m.target.Name + ": " + msg.Error()
+		m.target = nil
+		return m, m.loadRepositories
+	case browser.OpenedMsg:
+		// The screens opened from here report their own URLs.
+		if m.status == statusOverview {
+			m.overview.SetStatus(msg.Status())
+			return m, nil
+		}
+		if m.status == statusReady {
+			m.statusMsg = msg.Status()
+			return m, nil
+		}
+	case statusMsg:
+		if m.status == statusOverview {
+			m.overview.SetStatus(string(msg))
+		} else {
+			m.statusMsg = string(msg)
+		}
+		return m, nil
+	case cloneProgressMsg:
+		return updateClone(m, msg)
+	case socialUpdatedMsg:
+		return updateSocial(m, msg)
+	case socialsLoadedMsg:
+		return updateSocials(m, msg)
+	case subscriptionLoadedMsg:
+		s := m.socialOf(msg.repo)
+		s.watch = msg.watch
+		return setSocial(m, msg.repo, s)
+	case forkOwnersLoadedMsg:
+		m.forkPrompt.owners = msg
+		return m, nil
+	case forkedMsg:
+		return updateFork(m, msg)
 	}
 
 	var cmds []tea.Cmd
@@ -215,9 +454,33 @@ func updateChildren(m Model, msg tea.Msg) (Model, tea.Cmd) {
 	case statusReady:
 		m.list, cmd = m.list.Update(msg)
 		m.list.NewStatusMessage(fmt.Sprintf("Index: %d, Cursor: %d, Visible: %d", m.list.Index(), m.list.Cursor(), len(m.list.VisibleItems())))
+	case statusOverview:
+		m.overview, cmd = m.overview.Update(msg)
+		if m.overview.Done {
+			m.status = statusReady
+		} else if m.overview.Open != overview.SectionNone {
+			section := m.overview.Open
+			m.overview.Open = overview.SectionNone
+			return openSection(m, section)
+		} else if m.overview.Action != overview.ActionNone {
+			action := m.overview.Action
+			m.overview.Action = overview.ActionNone
+			return runAction(m, action)
+		}
 	case statusRepositorySelected:
 		m.repository, cmd = m.repository.Update(msg)
 		if m.repository.Done {
+			if m.keys == nil {
+				// The list was skipped for a target repository; load it now.
+				m.status = statusInit
+				cmd = tea.Batch(cmd, m.loadRepositories, spinner.Tick)
+			} else {
+				m.status = statusOverview
+			}
+		}
+	case statusGists:
+		m.gists, cmd = m.gists.Update(msg)
+		if m.gists.Done {
 			m.status = statusReady
 		}
 	}
@@ -227,11 +490,53 @@ func updateChildren(m Model, msg tea.Msg) (Model, tea.Cmd) {
 func (m Model) View() string {
 	switch m.status {
 	case statusInit:
+		if m.target != nil {
+			return common.AppStyle().Render(m.spinner.View() + " Loading " + m.target.Owner + "/" + m.target.Name + "...")
+		}
+		if m.source != sourceMine {
+			return common.AppStyle().Render(m.spinner.View() + " Loading " + strings.ToLower(m.source.String()) + " repositories...")
+		}
 		return common.AppStyle().Render(m.spinner.View() + " Loading repositories...")
 	case statusReady:
-		return common.AppStyle().Render(m.list.View())
+		var panes []string
+		if m.filterMenu.open {
+			panes = append(panes, m.filterMenuView())
+		}
+		if m.watchMenu.open {
+			panes = append(panes, m.watchMenu.View())
+		}
+		if m.forkPrompt.open {
+			panes = append(panes, m.forkPrompt.View())
+		}
+		if m.errMsg != "" {
+			panes = append(panes, common.ErrorStyle().Render(m.errMsg))
+		}
+		if m.statusMsg != "" {
+			panes = append(panes, common.ListStatusMessageStyle().Render(m.statusMsg))
+		}
+		if clonePane := m.clone.View(m.list.Width()); clonePane != "" {
+			panes = append(panes, clonePane)
+		}
+		if len(panes) == 0 {
+			return common.AppStyle().Render(m.list.View())
+		}
+		extra := lipgloss.JoinVertical(lipgloss.Left, panes...)
+		m.list.SetHeight(m.list.Height() - lipgloss.Height(extra))
+		return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, m.list.View(), extra))
+	case statusOverview:
+		switch {
+		case m.watchMenu.open:
+			m.overview.SetFooter(m.watchMenu.View())
+		case m.forkPrompt.open:
+			m.overview.SetFooter(m.forkPrompt.View())
+		default:
+			m.overview.SetFooter("")
+		}
+		return m.overview.View()
 	case statusRepositorySelected:
 		return m.repository.View()
+	case statusGists:
+		return m.gists.View()
 	}
 	return ""
 }
@@ -241,30 +546,141 @@ func (m Model) loadRepositories() tea.Msg {
 		return spinner.Tick
 	}
 	m.status = statusLoading
-	opts := &github.ListOptions{
-		Page:    1,
-		PerPage: 200,
-	}
 
+	starredAt := map[string]time.Time{}
+	var affiliations map[string][]string
 	var repos []*github.Repository
 	var err error
-	// gh.Teams.ListTeamReposBySlug(context.Background(), org, team, opts)
-	// gh.Repositories.ListByOrg(context.Background(), org, opts)
-	repos, _, err = m.gh.Repositories.List(context.Background(), *m.user.Login, &github.RepositoryListOptions{
-		ListOptions: *opts,
-	})
-	if err != nil {
-		log.Fatal("Could not get repositories.")
+	switch m.source {
+	case sourceStarred:
+		var starred []*github.StarredRepository
+		starred, err = m.listStarred()
+		for _, s := range starred {
+			repos = append(repos, s.GetRepository())
+			starredAt[s.GetRepository().GetFullName()] = s.GetStarredAt().Time
+		}
+	case sourceWatched:
+		repos, err = m.listWatched()
+	default:
+		// gh.Teams.ListTeamReposBySlug(context.Background(), org, team, opts)
+		// gh.Repositories.ListByOrg(context.Background(), org, opts)
+		repos, affiliations, err = m.listAffiliated()
 	}
 
 	items := make([]list.Item, len(repos))
 	for i := 0; i < len(repos); i++ {
 		repo := repos[i]
-		description := "The " + *repo.FullName + " repository."
-		if repo.Description != nil {
-			description = *repo.Description
+		s, ok := m.socials[repo.GetFullName()]
+		if !ok {
+			s = social{watch: watchParticipating}
+		}
+		listItem := m.newItem(repo, s)
+		listItem.starredAt = starredAt[repo.GetFullName()]
+		listItem.affiliations = affiliations[repo.GetFullName()]
+		items[i] = listItem
+	}
+	return repositoriesLoadedMsg{m.source, repos, items, err}
+}
+
+func (m Model) newItem(repo *github.Repository, s social) item {
+	description := "The " + *repo.FullName + " repository."
+	if repo.Description != nil {
+		description = *repo.Description
+	}
+	name := *repo.Name
+	if repo.GetOwner().GetLogin() != m.user.GetLogin() {
+		name = *repo.FullName
+	}
+	return item{name: name, description: description, repo: repo, cloned: m.isCloned(repo), social: s}
+}
+
+func (m Model) loadTarget() tea.Msg {
+	repo, _, err := m.gh.Repositories.Get(context.Background(), m.target.Owner, m.target.Name)
+	if err != nil {
+		return targetErrorMsg(err)
+	}
+	ref := m.target.Ref
+	if ref == "" {
+		ref = repo.GetDefaultBranch()
+	}
+	return targetLoadedMsg{repo: repo, ref: ref}
+}
+
+func (m Model) openInBrowser(repo *github.Repository) tea.Cmd {
+	return browser.OpenCmd(m.config, repo.GetHTMLURL())
+}
+
+// openSection leaves the overview for the chosen part of the repository.
+// Every section opens in its own screen of the repository.
+func openSection(m Model, section overview.Section) (Model, tea.Cmd) {
+	repo := m.overview.Repository()
+	switch section {
+	case overview.SectionFiles:
+		m.status = statusRepositorySelected
+		m.repository = repository.NewModel(m.user, repo, m.gh, m.config)
+		return m, m.repository.Init()
+	case overview.SectionIssues:
+		m.status = statusRepositorySelected
+		m.repository = repository.NewModel(m.user, repo, m.gh, m.config)
+		var cmd tea.Cmd
+		m.repository, cmd = m.repository.OpenIssues()
+		return m, tea.Batch(m.repository.Init(), cmd)
+	case overview.SectionPullRequests:
+		m.status = statusRepositorySelected
+		m.repository = repository.NewModel(m.user, repo, m.gh, m.config)
+		var cmd tea.Cmd
+		m.repository, cmd = m.repository.OpenPullRequests()
+		return m, tea.Batch(m.repository.Init(), cmd)
+	case overview.SectionActions:
+		m.status = statusRepositorySelected
+		m.repository = repository.NewModel(m.user, repo, m.gh, m.config)
+		var cmd tea.Cmd
+		m.repository, cmd = m.repository.OpenActions()
+		return m, tea.Batch(m.repository.Init(), cmd)
+	case overview.SectionReleases:
+		m.status = statusRepositorySelected
+		m.repository = repository.NewModel(m.user, repo, m.gh, m.config)
+		var cmd tea.Cmd
+		m.repository, cmd = m.repository.OpenReleases()
+		return m, tea.Batch(m.repository.Init(), cmd)
+	}
+	return m, nil
+}
+
+// runAction runs an action the overview asked for on its repository.
+func runAction(m Model, action overview.Action) (Model, tea.Cmd) {
+	repo := m.overview.Repository()
+	switch action {
+	case overview.ActionStar:
+		return toggleStar(m, repo)
+	case overview.ActionWatch:
+		return openWatchMenu(m, repo)
+	case overview.ActionFork:
+		return openForkPrompt(m, repo)
+	case overview.ActionOpen:
+		return m, m.openInBrowser(repo)
+	case overview.ActionCopyPermalink:
+		return m, m.copyPermalink(repo)
+	}
+	return m, nil
+}
+
+// copyPermalink copies the URL of the repository pinned to the commit its
+// default branch points to.
+func (m Model) copyPermalink(repo *github.Repository) tea.Cmd {
+	return func() tea.Msg {
+		sha, _, err := m.gh.Repositories.GetCommitSHA1(context.Background(), repo.GetOwner().GetLogin(), repo.GetName(), repo.GetDefaultBranch(), "")
+		if err != nil {
+			return statusMsg("Could not resolve " + repo.GetDefaultBranch() + ": " + err.Error())
+		}
+		u := repo.GetHTMLURL() + "/tree/" + sha
+		if err := browser.Copy(u); err != nil {
+			return statusMsg("Could not copy " + u + ": " + err.Error())
 		}
-		items[i] = item{name: *repo.Name, description: description}
+		return statusMsg("Copied " + u)
 	}
-	return repositoriesLoadedMsg{repos, items}
+}
+
+func (m Model) title() string {
+	return *m.user.Login + " " + m.source.sourcesView() + " (by " + m.sort.String() + m.filterSummary() + ")"
 }
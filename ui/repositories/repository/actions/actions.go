@@ -0,0 +1,545 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/browser"
+	"ghtui/ghtui/config"
+	"ghtui/ghtui/ui/common"
+)
+
+const (
+	// refreshInterval is how often runs are reloaded while any is in progress.
+	refreshInterval = 5 * time.Second
+	runsPerPage     = 50
+)
+
+// run is a workflow run with the fields go-github doesn't decode yet.
+type run struct {
+	*github.WorkflowRun
+	Actor        *github.User      `json:"actor,omitempty"`
+	RunStartedAt *github.Timestamp `json:"run_started_at,omitempty"`
+	DisplayTitle *string           `json:"display_title,omitempty"`
+}
+
+type workflowRuns struct {
+	TotalCount   int    `json:"total_count"`
+	WorkflowRuns []*run `json:"workflow_runs"`
+}
+
+type runsLoadedMsg struct {
+	generation int
+	runs       []*run
+	total      int
+}
+type workflowsLoadedMsg []*github.Workflow
+type refreshMsg int
+type errorMsg error
+type statusMsg string
+
+// actionDoneMsg reports an action that changed the runs, which are reloaded.
+type actionDoneMsg string
+
+// confirmation asks before running an action on a run.
+type confirmation struct {
+	prompt string
+	action tea.Cmd
+}
+
+type status int
+
+const (
+	statusLoading status = iota
+	statusReady
+)
+
+type Model struct {
+	Done bool
+
+	repository *github.Repository
+	gh         *github.Client
+	config     *config.Config
+	spinner    spinner.Model
+	status     status
+	statusMsg  string
+	runs       []*run
+	total      int
+	index      int
+	offset     int
+	workflows  []*github.Workflow
+	workflow   int
+	branch     string
+	prompting  bool
+	input      input.Model
+	// generation tells the latest load apart from stale ones, so only one
+	// auto refresh is ever scheduled.
+	generation int
+	width      int
+	height     int
+	// run is the workflow run whose jobs are shown, if any.
+	run      *runModel
+	confirm  *confirmation
+	dispatch *dispatchForm
+}
+
+func NewModel(repository *github.Repository, gh *github.Client, cfg *config.Config) Model {
+	width, height := common.ScreenSize()
+	top, right, bottom, left := common.AppStyle().GetPadding()
+	return Model{
+		repository: repository,
+		gh:         gh,
+		config:     cfg,
+		spinner:    common.NewSpinnerModel(),
+		status:     statusLoading,
+		width:      width - left - right,
+		height:     height - top - bottom,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.loadWorkflows, m.loadRuns(m.generation), spinner.Tick)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if m.run != nil {
+		run, cmd := m.run.Update(msg)
+		if run.done {
+			m.run = nil
+			return m, nil
+		}
+		m.run = &run
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return m, cmd
+		}
+		// Keep refreshing the runs in the background.
+		var own tea.Cmd
+		m, own = m.updateRuns(msg)
+		return m, common.BatchCommands(cmd, own)
+	}
+	return m.updateRuns(msg)
+}
+
+func (m Model) updateRuns(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.prompting {
+			return updateBranchPrompt(m, msg)
+		}
+		if m.dispatch != nil {
+			return updateDispatchForm(m, msg)
+		}
+		if m.confirm != nil {
+			confirm := m.confirm
+			m.confirm = nil
+			if msg.String() == "y" {
+				m.statusMsg = ""
+				return m, confirm.action
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc":
+			m.Done = true
+		case "up", "k":
+			m.move(-1)
+		case "down", "j":
+			m.move(1)
+		case "pgup":
+			m.move(-m.visibleRows())
+		case "pgdown":
+			m.move(m.visibleRows())
+		case "w":
+			m.workflow = (m.workflow + 1) % (len(m.workflows) + 1)
+			return m.reload()
+		case "W":
+			m.workflow = (m.workflow + len(m.workflows)) % (len(m.workflows) + 1)
+			return m.reload()
+		case "b":
+			return m.promptBranch()
+		case "r":
+			return m.reload()
+		case "o":
+			if r, ok := m.selected(); ok {
+				return m, browser.OpenCmd(m.config, r.GetHTMLURL())
+			}
+		case "y":
+			if r, ok := m.selected(); ok {
+				return m, browser.CopyCmd(r.GetHTMLURL())
+			}
+		case "enter":
+			if r, ok := m.selected(); ok {
+				run := newRunModel(r, m.repository, m.gh)
+				m.run = &run
+				return m, run.Init()
+			}
+		case "R":
+			if r, ok := m.selected(); ok && r.GetStatus() == "completed" {
+				m.confirm = &confirmation{
+					prompt: fmt.Sprintf("Re-run all jobs of %s #%d? (y/n)", r.GetName(), r.GetRunNumber()),
+					action: m.rerun(r, false),
+				}
+			}
+		case "F":
+			if r, ok := m.selected(); ok && r.GetStatus() == "completed" {
+				m.confirm = &confirmation{
+					prompt: fmt.Sprintf("Re-run the failed jobs of %s #%d? (y/n)", r.GetName(), r.GetRunNumber()),
+					action: m.rerun(r, true),
+				}
+			}
+		case "c":
+			if r, ok := m.selected(); ok && r.GetStatus() != "completed" {
+				m.confirm = &confirmation{
+					prompt: fmt.Sprintf("Cancel %s #%d? (y/n)", r.GetName(), r.GetRunNumber()),
+					action: m.cancel(r),
+				}
+			}
+		case "d":
+			if workflow := m.dispatchTarget(); workflow != nil {
+				ref := m.branch
+				if ref == "" {
+					ref = m.repository.GetDefaultBranch()
+				}
+				m.statusMsg = "Loading the inputs of " + workflow.GetName() + "..."
+				return m, m.loadDispatchForm(workflow, ref)
+			}
+			m.statusMsg = "Choose a workflow with w first."
+		}
+	case tea.WindowSizeMsg:
+		top, right, bottom, left := common.AppStyle().GetPadding()
+		m.width = msg.Width - left - right
+		m.height = msg.Height - top - bottom
+	case spinner.TickMsg:
+		m.spinner, cmd = m.spinner.Update(msg)
+	case workflowsLoadedMsg:
+		m.workflows = msg
+	case runsLoadedMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		m.status = statusReady
+		m.statusMsg = ""
+		m.runs = msg.runs
+		m.total = msg.total
+		m.move(0)
+		if m.inProgress() {
+			generation := m.generation
+			cmd = tea.Tick(refreshInterval, func(time.Time) tea.Msg {
+				return refreshMsg(generation)
+			})
+		}
+	case refreshMsg:
+		if int(msg) == m.generation {
+			m.generation++
+			return m, m.loadRuns(m.generation)
+		}
+	case statusMsg:
+		m.statusMsg = string(msg)
+	case browser.OpenedMsg:
+		m.statusMsg = msg.Status()
+	case browser.CopiedMsg:
+		m.statusMsg = msg.Status()
+	case actionDoneMsg:
+		m, cmd = m.reload()
+		m.statusMsg = string(msg)
+	case dispatchFormLoadedMsg:
+		return updateDispatchFormLoaded(m, msg)
+	case errorMsg:
+		m.status = statusReady
+		m.statusMsg = msg.Error()
+	}
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if m.run != nil {
+		return m.run.View()
+	}
+	title := common.ListTitleStyle().Render(m.repository.GetName() + " · Actions")
+	if m.status == statusLoading {
+		return common.AppStyle().Render(title + "\n\n" + m.spinner.View() + " Loading workflow runs...")
+	}
+
+	filters := "workflow: " + m.workflowName() + "  branch: "
+	if m.branch == "" {
+		filters += "all"
+	} else {
+		filters += m.branch
+	}
+	filters += fmt.Sprintf("  (%d of %d runs)", len(m.runs), m.total)
+	if m.inProgress() {
+		filters += "  " + m.spinner.View() + " auto-refreshing"
+	}
+
+	var rows []string
+	end := m.offset + m.visibleRows()
+	if end > len(m.runs) {
+		end = len(m.runs)
+	}
+	for i := m.offset; i < end; i++ {
+		row := truncate.StringWithTail(m.runs[i].row(), uint(m.width), "…")
+		if i == m.index {
+			row = common.PaneSelectedItemStyle().Render(row)
+		}
+		rows = append(rows, row)
+	}
+	if len(m.runs) == 0 {
+		rows = append(rows, "No workflow runs.")
+	}
+
+	footer := lipgloss.NewStyle().Foreground(common.GrayColor()).
+		Render("enter jobs and logs · w/W workflow · b branch · r refresh · R/F re-run all/failed · c cancel · d dispatch · o open in browser · y copy link · esc back")
+	if m.dispatch != nil {
+		return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, title, "", m.dispatch.View(), m.statusMsg))
+	}
+	if m.confirm != nil {
+		footer = m.confirm.prompt
+	} else if m.prompting {
+		footer = m.input.View()
+	} else if m.statusMsg != "" {
+		footer = m.statusMsg
+	}
+	return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		lipgloss.NewStyle().Foreground(common.GrayColor()).Render(filters),
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		footer,
+	))
+}
+
+// visibleRows is how many runs fit between the header and the footer.
+func (m Model) visibleRows() int {
+	if rows := m.height - 6; rows > 1 {
+		return rows
+	}
+	return 1
+}
+
+// move shifts the selection and scrolls it into view.
+func (m *Model) move(delta int) {
+	m.index += delta
+	if m.index >= len(m.runs) {
+		m.index = len(m.runs) - 1
+	}
+	if m.index < 0 {
+		m.index = 0
+	}
+	if m.index < m.offset {
+		m.offset = m.index
+	} else if m.index >= m.offset+m.visibleRows() {
+		m.offset = m.index - m.visibleRows() + 1
+	}
+}
+
+func (m Model) selected() (*run, bool) {
+	if m.index < 0 || m.index >= len(m.runs) {
+		return nil, false
+	}
+	return m.runs[m.index], true
+}
+
+func (m Model) inProgress() bool {
+	for _, r := range m.runs {
+		if r.GetStatus() != "completed" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) workflowName() string {
+	if m.workflow == 0 || m.workflow > len(m.workflows) {
+		return "all"
+	}
+	return m.workflows[m.workflow-1].GetName()
+}
+
+// reload fetches the runs again, dropping any pending auto refresh.
+func (m Model) reload() (Model, tea.Cmd) {
+	m.generation++
+	m.statusMsg = "Loading workflow runs..."
+	return m, m.loadRuns(m.generation)
+}
+
+func (m Model) promptBranch() (Model, tea.Cmd) {
+	m.input = input.NewModel()
+	m.input.Prompt = "Branch: "
+	m.input.Placeholder = "all branches"
+	m.input.SetValue(m.branch)
+	m.input.CursorEnd()
+	m.input.Focus()
+	m.prompting = true
+	return m, input.Blink
+}
+
+func updateBranchPrompt(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEscape:
+		m.prompting = false
+		return m, nil
+	case tea.KeyEnter:
+		m.prompting = false
+		m.branch = strings.TrimSpace(m.input.Value())
+		return m.reload()
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) owner() string {
+	return m.repository.GetOwner().GetLogin()
+}
+
+func (m Model) loadWorkflows() tea.Msg {
+	workflows, _, err := m.gh.Actions.ListWorkflows(context.Background(), m.owner(), m.repository.GetName(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return errorMsg(err)
+	}
+	return workflowsLoadedMsg(workflows.Workflows)
+}
+
+// loadRuns fetches the latest runs matching the filters. The runs are decoded
+// by hand as go-github leaves out the actor and start time.
+func (m Model) loadRuns(generation int) tea.Cmd {
+	endpoint := fmt.Sprintf("repos/%s/%s/actions/runs", url.PathEscape(m.owner()), url.PathEscape(m.repository.GetName()))
+	if m.workflow > 0 && m.workflow <= len(m.workflows) {
+		endpoint = fmt.Sprintf("repos/%s/%s/actions/workflows/%d/runs", url.PathEscape(m.owner()), url.PathEscape(m.repository.GetName()), m.workflows[m.workflow-1].GetID())
+	}
+	query := url.Values{"per_page": {fmt.Sprint(runsPerPage)}}
+	if m.branch != "" {
+		query.Set("branch", m.branch)
+	}
+	endpoint += "?" + query.Encode()
+
+	return func() tea.Msg {
+		req, err := m.gh.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return errorMsg(err)
+		}
+		var runs workflowRuns
+		if _, err := m.gh.Do(context.Background(), req, &runs); err != nil {
+			return errorMsg(err)
+		}
+		return runsLoadedMsg{generation: generation, runs: runs.WorkflowRuns, total: runs.TotalCount}
+	}
+}
+
+// dispatchTarget is the workflow to dispatch: the one filtered by, or else
+// the one of the selected run.
+func (m Model) dispatchTarget() *github.Workflow {
+	if m.workflow > 0 && m.workflow <= len(m.workflows) {
+		return m.workflows[m.workflow-1]
+	}
+	if r, ok := m.selected(); ok {
+		for _, workflow := range m.workflows {
+			if workflow.GetID() == r.GetWorkflowID() {
+				return workflow
+			}
+		}
+	}
+	return nil
+}
+
+// rerun re-runs every job of r, or only the failed ones. go-github has no
+// call for the latter yet.
+func (m Model) rerun(r *run, failedOnly bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		var err error
+		if failedOnly {
+			var req *http.Request
+			endpoint := fmt.Sprintf("repos/%s/%s/actions/runs/%d/rerun-failed-jobs", url.PathEscape(m.owner()), url.PathEscape(m.repository.GetName()), r.GetID())
+			req, err = m.gh.NewRequest(http.MethodPost, endpoint, nil)
+			if err == nil {
+				_, err = m.gh.Do(ctx, req, nil)
+			}
+		} else {
+			_, err = m.gh.Actions.RerunWorkflowByID(ctx, m.owner(), m.repository.GetName(), r.GetID())
+		}
+		if err != nil {
+			return statusMsg(fmt.Sprintf("Could not re-run #%d: %s", r.GetRunNumber(), err))
+		}
+		return actionDoneMsg(fmt.Sprintf("Re-running %s #%d.", r.GetName(), r.GetRunNumber()))
+	}
+}
+
+func (m Model) cancel(r *run) tea.Cmd {
+	return func() tea.Msg {
+		_, err := m.gh.Actions.CancelWorkflowRunByID(context.Background(), m.owner(), m.repository.GetName(), r.GetID())
+		if err != nil {
+			var accepted *github.AcceptedError
+			if !errors.As(err, &accepted) {
+				return statusMsg(fmt.Sprintf("Could not cancel #%d: %s", r.GetRunNumber(), err))
+			}
+		}
+		return actionDoneMsg(fmt.Sprintf("Cancelling %s #%d.", r.GetName(), r.GetRunNumber()))
+	}
+}
+
+// icon shows the status of a run, or its conclusion once completed.
+func (r *run) icon() string {
+	return common.StatusIcon(r.GetStatus(), r.GetConclusion())
+}
+
+// title is what the run is about, usually the head commit message.
+func (r *run) title() string {
+	if r.DisplayTitle != nil {
+		return *r.DisplayTitle
+	}
+	title := r.GetHeadCommit().GetMessage()
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = title[:i]
+	}
+	return title
+}
+
+func (r *run) startedAt() time.Time {
+	if r.RunStartedAt != nil {
+		return r.RunStartedAt.Time
+	}
+	return r.GetCreatedAt().Time
+}
+
+// duration is how long the run took, or has been running for so far.
+func (r *run) duration() time.Duration {
+	end := time.Now()
+	if r.GetStatus() == "completed" {
+		end = r.GetUpdatedAt().Time
+	}
+	return end.Sub(r.startedAt())
+}
+
+func (r *run) row() string {
+	return fmt.Sprintf("%s %-6s %-20s %-40s %-20s %-14s %-16s %8s  %s",
+		r.icon(),
+		fmt.Sprintf("#%d", r.GetRunNumber()),
+		cell(r.GetName(), 20),
+		cell(r.title(), 40),
+		cell(r.GetHeadBranch(), 20),
+		cell(r.GetEvent(), 14),
+		cell(r.Actor.GetLogin(), 16),
+		common.FormatDuration(r.duration()),
+		common.RelativeTime(r.GetCreatedAt().Time),
+	)
+}
+
+// cell truncates s to a table column.
+func cell(s string, width int) string {
+	return truncate.StringWithTail(s, uint(width), "…")
+}
@@ -0,0 +1,393 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+	"gopkg.in/yaml.v3"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// workflowInput is an input declared for the workflow_dispatch trigger.
+type workflowInput struct {
+	name        string
+	Description string      `yaml:"description"`
+	Required    bool        `yaml:"required"`
+	Default     interface{} `yaml:"default"`
+	Type        string      `yaml:"type"`
+	Options     []string    `yaml:"options"`
+}
+
+// dispatchFormLoadedMsg carries the inputs of a workflow as declared at ref.
+type dispatchFormLoadedMsg struct {
+	workflow *github.Workflow
+	ref      string
+	inputs   []workflowInput
+	err      error
+}
+
+// dispatchField edits the value of one workflow input.
+type dispatchField struct {
+	input   workflowInput
+	text    input.Model
+	choice  int
+	checked bool
+}
+
+// dispatchForm collects the ref and inputs to dispatch a workflow with.
+type dispatchForm struct {
+	workflow *github.Workflow
+	ref      input.Model
+	// loadedRef is the ref the inputs were read at. They are read again
+	// before dispatching on another one.
+	loadedRef string
+	fields    []dispatchField
+	// focus is 0 for the ref and i+1 for fields[i].
+	focus int
+	err   string
+}
+
+// parseDispatchInputs returns the inputs of the workflow_dispatch trigger in
+// a workflow file, in the order they are declared. It reports false if the
+// workflow can't be dispatched manually.
+func parseDispatchInputs(data []byte) ([]workflowInput, bool, error) {
+	var file struct {
+		On yaml.Node `yaml:"on"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, false, err
+	}
+
+	on := file.On
+	switch on.Kind {
+	case yaml.ScalarNode:
+		return nil, on.Value == "workflow_dispatch", nil
+	case yaml.SequenceNode:
+		for _, event := range on.Content {
+			if event.Value == "workflow_dispatch" {
+				return nil, true, nil
+			}
+		}
+		return nil, false, nil
+	case yaml.MappingNode:
+	default:
+		return nil, false, nil
+	}
+
+	dispatch := mappingValue(&on, "workflow_dispatch")
+	if dispatch == nil {
+		return nil, false, nil
+	}
+	inputsNode := mappingValue(dispatch, "inputs")
+	if inputsNode == nil || inputsNode.Kind != yaml.MappingNode {
+		return nil, true, nil
+	}
+	var inputs []workflowInput
+	for i := 0; i+1 < len(inputsNode.Content); i += 2 {
+		var in workflowInput
+		if err := inputsNode.Content[i+1].Decode(&in); err != nil {
+			return nil, true, err
+		}
+		in.name = inputsNode.Content[i].Value
+		inputs = append(inputs, in)
+	}
+	return inputs, true, nil
+}
+
+// mappingValue returns the value of key in a mapping node, if present.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// loadDispatchForm fetches the workflow file at ref to find out its inputs.
+func (m Model) loadDispatchForm(workflow *github.Workflow, ref string) tea.Cmd {
+	return func() tea.Msg {
+		msg := dispatchFormLoadedMsg{workflow: workflow, ref: ref}
+		file, _, _, err := m.gh.Repositories.GetContents(
+			context.Background(),
+			m.owner(),
+			m.repository.GetName(),
+			workflow.GetPath(),
+			&github.RepositoryContentGetOptions{Ref: ref},
+		)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		contents, err := file.GetContent()
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		inputs, ok, err := parseDispatchInputs([]byte(contents))
+		switch {
+		case err != nil:
+			msg.err = fmt.Errorf("could not parse %s at %s: %w", workflow.GetPath(), ref, err)
+		case !ok:
+			msg.err = fmt.Errorf("%s has no workflow_dispatch trigger at %s and can't be run manually", workflow.GetName(), ref)
+		default:
+			msg.inputs = inputs
+		}
+		return msg
+	}
+}
+
+// updateDispatchFormLoaded opens the form, or replaces its inputs with the
+// ones declared at the ref the user chose.
+func updateDispatchFormLoaded(m Model, msg dispatchFormLoadedMsg) (Model, tea.Cmd) {
+	open := m.dispatch
+	if open != nil && open.workflow.GetID() != msg.workflow.GetID() {
+		return m, nil
+	}
+	if msg.err != nil {
+		if open != nil {
+			open.err = msg.err.Error()
+		} else {
+			m.statusMsg = msg.err.Error()
+		}
+		return m, nil
+	}
+	form := newDispatchForm(msg.workflow, msg.inputs, msg.ref)
+	if open != nil {
+		form.keepValues(*open)
+		m.statusMsg = "Read the inputs at " + msg.ref + "; press enter to run."
+	} else {
+		m.statusMsg = ""
+	}
+	m.dispatch = &form
+	return m, input.Blink
+}
+
+func newDispatchForm(workflow *github.Workflow, inputs []workflowInput, ref string) dispatchForm {
+	form := dispatchForm{workflow: workflow, loadedRef: ref}
+	form.ref = input.NewModel()
+	form.ref.Prompt = ""
+	form.ref.SetValue(ref)
+	form.ref.CursorEnd()
+	form.ref.Focus()
+	for _, in := range inputs {
+		field := dispatchField{input: in}
+		value := ""
+		if in.Default != nil {
+			value = fmt.Sprint(in.Default)
+		}
+		switch in.Type {
+		case "boolean":
+			field.checked = value == "true"
+		case "choice":
+			for i, option := range in.Options {
+				if option == value {
+					field.choice = i
+				}
+			}
+		default:
+			field.text = input.NewModel()
+			field.text.Prompt = ""
+			field.text.SetValue(value)
+			field.text.CursorEnd()
+		}
+		form.fields = append(form.fields, field)
+	}
+	return form
+}
+
+// keepValues carries what was entered in previous over to the inputs of the
+// same name and type.
+func (f *dispatchForm) keepValues(previous dispatchForm) {
+	f.ref.SetValue(previous.ref.Value())
+	f.ref.CursorEnd()
+	for i := range f.fields {
+		field := &f.fields[i]
+		for _, old := range previous.fields {
+			if old.input.name != field.input.name || old.input.Type != field.input.Type {
+				continue
+			}
+			switch field.input.Type {
+			case "boolean":
+				field.checked = old.checked
+			case "choice":
+				if old.choice < len(old.input.Options) {
+					for j, option := range field.input.Options {
+						if option == old.input.Options[old.choice] {
+							field.choice = j
+						}
+					}
+				}
+			default:
+				field.text.SetValue(old.text.Value())
+				field.text.CursorEnd()
+			}
+		}
+	}
+}
+
+func updateDispatchForm(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	form := m.dispatch
+	switch msg.String() {
+	case "esc":
+		m.dispatch = nil
+		return m, nil
+	case "tab", "down":
+		form.setFocus((form.focus + 1) % (len(form.fields) + 1))
+		return m, input.Blink
+	case "shift+tab", "up":
+		form.setFocus((form.focus + len(form.fields)) % (len(form.fields) + 1))
+		return m, input.Blink
+	case "enter":
+		ref, inputs, err := form.values()
+		if err != nil {
+			form.err = err.Error()
+			return m, nil
+		}
+		if ref != form.loadedRef {
+			form.err = ""
+			m.statusMsg = "Loading the inputs of " + form.workflow.GetName() + " at " + ref + "..."
+			return m, m.loadDispatchForm(form.workflow, ref)
+		}
+		m.dispatch = nil
+		m.statusMsg = "Dispatching " + form.workflow.GetName() + " on " + ref + "..."
+		return m, m.dispatchWorkflow(form.workflow, ref, inputs)
+	}
+
+	if form.focus == 0 {
+		var cmd tea.Cmd
+		form.ref, cmd = form.ref.Update(msg)
+		return m, cmd
+	}
+	field := &form.fields[form.focus-1]
+	switch field.input.Type {
+	case "boolean":
+		if msg.String() == " " || msg.String() == "x" {
+			field.checked = !field.checked
+		}
+	case "choice":
+		switch msg.String() {
+		case "left", "h":
+			if field.choice > 0 {
+				field.choice--
+			}
+		case "right", "l", " ":
+			if field.choice < len(field.input.Options)-1 {
+				field.choice++
+			}
+		}
+	default:
+		var cmd tea.Cmd
+		field.text, cmd = field.text.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (f *dispatchForm) setFocus(focus int) {
+	f.ref.Blur()
+	for i := range f.fields {
+		f.fields[i].text.Blur()
+	}
+	f.focus = focus
+	if focus == 0 {
+		f.ref.Focus()
+	} else {
+		f.fields[focus-1].text.Focus()
+	}
+}
+
+// values checks the form and returns what to dispatch the workflow with.
+func (f dispatchForm) values() (string, map[string]interface{}, error) {
+	ref := strings.TrimSpace(f.ref.Value())
+	if ref == "" {
+		return "", nil, fmt.Errorf("a branch or tag is required")
+	}
+	inputs := map[string]interface{}{}
+	for _, field := range f.fields {
+		var value string
+		switch field.input.Type {
+		case "boolean":
+			value = fmt.Sprint(field.checked)
+		case "choice":
+			if len(field.input.Options) > 0 {
+				value = field.input.Options[field.choice]
+			}
+		default:
+			value = field.text.Value()
+		}
+		if value == "" && field.input.Required {
+			return "", nil, fmt.Errorf("%s is required", field.input.name)
+		}
+		// The API takes every input as a string, whatever its type.
+		inputs[field.input.name] = value
+	}
+	return ref, inputs, nil
+}
+
+func (f dispatchForm) View() string {
+	// Input names are longer than the labels of other forms.
+	label := lipgloss.NewStyle().Width(20)
+	cursor := func(focus int) string {
+		return common.FormCursor(f.focus == focus)
+	}
+
+	lines := []string{
+		"Run workflow " + f.workflow.GetName(),
+		"",
+		cursor(0) + label.Render("Branch or tag *") + f.ref.View(),
+	}
+	for i, field := range f.fields {
+		name := field.input.name
+		if field.input.Required {
+			name += " *"
+		}
+		var value string
+		switch field.input.Type {
+		case "boolean":
+			value = common.Checkbox(field.checked)
+		case "choice":
+			var options []string
+			for j, option := range field.input.Options {
+				if j == field.choice {
+					option = common.PaneSelectedItemStyle().Render(" " + option + " ")
+				} else {
+					option = " " + option + " "
+				}
+				options = append(options, option)
+			}
+			value = strings.Join(options, "")
+		default:
+			value = field.text.View()
+		}
+		lines = append(lines, cursor(i+1)+label.Render(truncate.StringWithTail(name, 19, "…"))+value)
+		if description := field.input.Description; description != "" {
+			lines = append(lines, "  "+label.Render("")+lipgloss.NewStyle().Foreground(common.GrayColor()).Render(description))
+		}
+	}
+	lines = append(lines, "")
+	return common.FormView(lines, f.err, "tab/↑/↓ move · space toggle · ←/→ choose · enter run · esc cancel")
+}
+
+func (m Model) dispatchWorkflow(workflow *github.Workflow, ref string, inputs map[string]interface{}) tea.Cmd {
+	return func() tea.Msg {
+		_, err := m.gh.Actions.CreateWorkflowDispatchEventByID(context.Background(), m.owner(), m.repository.GetName(), workflow.GetID(), github.CreateWorkflowDispatchEventRequest{
+			Ref:    ref,
+			Inputs: inputs,
+		})
+		if err != nil {
+			return statusMsg("Could not run " + workflow.GetName() + ": " + err.Error())
+		}
+		return actionDoneMsg("Dispatched " + workflow.GetName() + " on " + ref + ".")
+	}
+}
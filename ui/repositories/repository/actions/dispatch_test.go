@@ -0,0 +1,68 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDispatchInputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow string
+		inputs   []workflowInput
+		ok       bool
+	}{
+		{"scalar", "on: workflow_dispatch\n", nil, true},
+		{"other scalar", "on: push\n", nil, false},
+		{"sequence", "on: [push, workflow_dispatch]\n", nil, true},
+		{"other sequence", "on: [push, pull_request]\n", nil, false},
+		{"null", "on:\n  push:\n  workflow_dispatch:\n", nil, true},
+		{"no inputs", "on:\n  workflow_dispatch:\n    inputs: {}\n", nil, true},
+		{"other mapping", "on:\n  push:\n    branches: [main]\n", nil, false},
+		{"no on", "name: CI\n", nil, false},
+		{
+			"inputs",
+			`on:
+  workflow_dispatch:
+    inputs:
+      environment:
+        description: Where to deploy
+        required: true
+        type: choice
+        options: [staging, production]
+        default: staging
+      dry-run:
+        type: boolean
+        default: true
+      count:
+        default: 3
+`,
+			[]workflowInput{
+				{name: "environment", Description: "Where to deploy", Required: true, Type: "choice", Options: []string{"staging", "production"}, Default: "staging"},
+				{name: "dry-run", Type: "boolean", Default: true},
+				{name: "count", Default: 3},
+			},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, ok, err := parseDispatchInputs([]byte(tt.workflow))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok {
+				t.Errorf("dispatchable is %t, want %t", ok, tt.ok)
+			}
+			if !reflect.DeepEqual(inputs, tt.inputs) {
+				t.Errorf("got inputs %+v, want %+v", inputs, tt.inputs)
+			}
+		})
+	}
+}
+
+func TestParseDispatchInputsInvalid(t *testing.T) {
+	if _, _, err := parseDispatchInputs([]byte("on: [push\n")); err == nil {
+		t.Error("parsed an invalid workflow")
+	}
+}
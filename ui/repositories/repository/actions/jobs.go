@@ -0,0 +1,514 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/ui/common"
+	"ghtui/ghtui/ui/repositories/repository/pane"
+)
+
+// maxPollInterval is the longest the jobs of a running workflow go without
+// being polled while GitHub keeps failing to list them.
+const maxPollInterval = time.Minute
+
+type jobsLoadedMsg struct {
+	runID int64
+	jobs  []*github.WorkflowJob
+	err   error
+}
+type jobLogLoadedMsg struct {
+	jobID int64
+	data  string
+	// final is set when the job had completed before its log was fetched,
+	// so the log won't grow anymore.
+	final bool
+	err   error
+}
+type pollMsg int
+
+// jobRow is a job, or one of its steps, in the left pane of the run view.
+type jobRow struct {
+	job  *github.WorkflowJob
+	step *github.TaskStep
+}
+
+// runModel shows the jobs and steps of a workflow run and their logs. Logs
+// of running jobs are polled and followed while scrolled to the bottom.
+type runModel struct {
+	done bool
+
+	repository *github.Repository
+	gh         *github.Client
+	run        *run
+	jobs       []*github.WorkflowJob
+	rows       []jobRow
+	index      int
+	paneIndex  int
+	leftPane   pane.Model
+	rightPane  pane.Model
+	statusMsg  string
+
+	// The log shown in the right pane.
+	job      *github.WorkflowJob
+	step     *github.TaskStep
+	lines    []logLine
+	logFinal bool
+	entries  []logEntry
+	shown    []int
+	expanded map[int]bool
+
+	generation int
+	// failures counts the polls in a row that failed, to back off.
+	failures int
+}
+
+func newRunModel(r *run, repository *github.Repository, gh *github.Client) runModel {
+	width, height := common.ScreenSize()
+	baseWidth := width / 4
+	top, right, bottom, _ := common.AppStyle().GetPadding()
+	paneHeight := height - top - bottom
+	m := runModel{
+		repository: repository,
+		gh:         gh,
+		run:        r,
+		leftPane:   pane.NewModel(baseWidth-right, paneHeight-3, true),
+		rightPane:  pane.NewModel(baseWidth*3-right, paneHeight-3, false),
+		expanded:   map[int]bool{},
+	}
+	m.rightPane.SetContent("Select a job or step and press enter to show its log.")
+	return m
+}
+
+func (m runModel) Init() tea.Cmd {
+	return m.loadJobs
+}
+
+func (m runModel) Update(msg tea.Msg) (runModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.paneIndex == 1 && m.rightPane.Prompting() {
+			m.rightPane, cmd = m.rightPane.Update(msg)
+			return m, cmd
+		}
+		switch msg.String() {
+		case "esc":
+			if m.paneIndex == 1 {
+				m.paneIndex = 0
+			} else {
+				m.done = true
+			}
+		case "tab":
+			m.paneIndex ^= 1
+		case "up", "k":
+			if m.paneIndex == 0 {
+				m.move(-1)
+			} else {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		case "down", "j":
+			if m.paneIndex == 0 {
+				m.move(1)
+			} else {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		case "enter":
+			if m.paneIndex == 0 {
+				return m.openLog()
+			}
+			m.toggleGroup()
+		case "z":
+			m.toggleGroup()
+		case "Z":
+			m.toggleAllGroups()
+		case "e":
+			m.nextError(1)
+		case "E":
+			m.nextError(-1)
+		default:
+			if m.paneIndex == 1 {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		}
+	case jobsLoadedMsg:
+		if msg.runID != m.run.GetID() {
+			return m, nil
+		}
+		if msg.err != nil {
+			// Keep following a running workflow through transient errors.
+			m.failures++
+			m.statusMsg = "Could not load the jobs: " + msg.err.Error()
+			if !m.running() {
+				return m, nil
+			}
+			return m, m.poll(pollDelay(m.failures))
+		}
+		if m.failures > 0 {
+			m.failures = 0
+			m.statusMsg = ""
+		}
+		m.setJobs(msg.jobs)
+		var cmds []tea.Cmd
+		if m.job != nil && !m.logFinal && m.job.GetStatus() == "completed" {
+			// Fetch the log once more now that it is complete.
+			cmds = append(cmds, m.loadLog(m.job))
+		}
+		if m.running() {
+			cmds = append(cmds, m.poll(refreshInterval))
+		}
+		return m, tea.Batch(cmds...)
+	case pollMsg:
+		if int(msg) != m.generation {
+			return m, nil
+		}
+		m.generation++
+		cmds := []tea.Cmd{m.loadJobs}
+		if m.job != nil && !m.logFinal && m.job.GetStatus() != "completed" {
+			cmds = append(cmds, m.loadLog(m.job))
+		}
+		return m, tea.Batch(cmds...)
+	case jobLogLoadedMsg:
+		if m.job == nil || msg.jobID != m.job.GetID() {
+			return m, nil
+		}
+		if msg.err != nil {
+			if m.job.GetStatus() != "completed" {
+				m.statusMsg = "The log is not available yet; waiting for the job..."
+			} else {
+				m.statusMsg = "Could not load the log: " + msg.err.Error()
+			}
+			return m, nil
+		}
+		m.statusMsg = ""
+		m.logFinal = msg.final
+		m.lines = parseLog(msg.data)
+		m.showLog(false)
+	case errorMsg:
+		m.statusMsg = msg.Error()
+	}
+	m.leftPane.Active = m.paneIndex == 0
+	m.rightPane.Active = m.paneIndex == 1
+	return m, cmd
+}
+
+func (m runModel) View() string {
+	m.leftPane.Viewport.SetContent(m.jobsView())
+	title := m.repository.GetName() + " · " + m.run.GetName() + fmt.Sprintf(" #%d", m.run.GetRunNumber())
+	if m.job != nil {
+		title += " · " + m.job.GetName()
+		if m.step != nil {
+			title += " · " + m.step.GetName()
+		}
+	}
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, m.leftPane.View(), m.rightPane.View())
+	statusBar := lipgloss.NewStyle().Foreground(common.GrayColor()).
+		Render("enter open/fold · z/Z fold group/all · e/E next/previous error · / search · tab switch pane · esc back")
+	if m.statusMsg != "" {
+		statusBar = m.statusMsg
+	} else if m.job != nil && !m.logFinal {
+		statusBar = "● following the log of a running job · " + statusBar
+	}
+	return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, common.ListTitleStyle().Render(title), panes, statusBar))
+}
+
+func (m runModel) jobsView() string {
+	var lines []string
+	width := uint(m.leftPane.Viewport.Width)
+	for i, row := range m.rows {
+		var line string
+		if row.step == nil {
+			line = common.StatusIcon(row.job.GetStatus(), row.job.GetConclusion()) + " " + row.job.GetName()
+			if start := row.job.GetStartedAt().Time; !start.IsZero() {
+				end := time.Now()
+				if row.job.CompletedAt != nil {
+					end = row.job.GetCompletedAt().Time
+				}
+				line += " " + common.FormatDuration(end.Sub(start))
+			}
+		} else {
+			line = "  " + common.StatusIcon(row.step.GetStatus(), row.step.GetConclusion()) + " " + row.step.GetName()
+		}
+		line = truncate.StringWithTail(line, width, "…")
+		if i == m.index {
+			line = common.PaneSelectedItemStyle().Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return "Loading jobs..."
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *runModel) move(delta int) {
+	m.index += delta
+	if m.index >= len(m.rows) {
+		m.index = len(m.rows) - 1
+	}
+	if m.index < 0 {
+		m.index = 0
+	}
+	if m.index < m.leftPane.Viewport.YOffset {
+		m.leftPane.Viewport.YOffset = m.index
+	} else if height := m.leftPane.Viewport.Height; height > 0 && m.index >= m.leftPane.Viewport.YOffset+height {
+		m.leftPane.Viewport.YOffset = m.index - height + 1
+	}
+}
+
+// setJobs replaces the jobs, keeping the selected row and the shown job and
+// step up to date.
+func (m *runModel) setJobs(jobs []*github.WorkflowJob) {
+	var selected jobRow
+	if m.index < len(m.rows) {
+		selected = m.rows[m.index]
+	}
+	m.jobs = jobs
+	m.rows = nil
+	for _, job := range jobs {
+		m.rows = append(m.rows, jobRow{job: job})
+		if selected.job != nil && job.GetID() == selected.job.GetID() && selected.step == nil {
+			m.index = len(m.rows) - 1
+		}
+		for _, step := range job.Steps {
+			m.rows = append(m.rows, jobRow{job: job, step: step})
+			if selected.step != nil && job.GetID() == selected.job.GetID() && step.GetNumber() == selected.step.GetNumber() {
+				m.index = len(m.rows) - 1
+			}
+		}
+		if m.job != nil && job.GetID() == m.job.GetID() {
+			m.job = job
+			for _, step := range job.Steps {
+				if m.step != nil && step.GetNumber() == m.step.GetNumber() {
+					m.step = step
+				}
+			}
+		}
+	}
+	m.move(0)
+}
+
+// running reports whether any job still has to finish.
+func (m runModel) running() bool {
+	if len(m.jobs) == 0 {
+		return m.run.GetStatus() != "completed"
+	}
+	for _, job := range m.jobs {
+		if job.GetStatus() != "completed" {
+			return true
+		}
+	}
+	return false
+}
+
+// openLog shows the log of the selected job, or only the part of it written
+// by the selected step.
+func (m runModel) openLog() (runModel, tea.Cmd) {
+	if m.index >= len(m.rows) {
+		return m, nil
+	}
+	row := m.rows[m.index]
+	m.paneIndex = 1
+	if m.job != nil && m.job.GetID() == row.job.GetID() {
+		m.step = row.step
+		m.showLog(true)
+		return m, nil
+	}
+	m.job = row.job
+	m.step = row.step
+	m.lines = nil
+	m.logFinal = false
+	m.expanded = map[int]bool{}
+	m.statusMsg = "Loading the log of " + row.job.GetName() + "..."
+	m.rightPane.SetContent("")
+	return m, m.loadLog(row.job)
+}
+
+// showLog renders the log of the shown job or step. A new selection starts at
+// the top, while a refreshed log keeps its scroll position.
+func (m *runModel) showLog(reset bool) {
+	if reset {
+		m.expanded = map[int]bool{}
+	}
+	m.entries = logEntries(stepLines(m.lines, m.step))
+	if len(m.entries) == 0 {
+		m.rightPane.SetContent("The log is empty.")
+		return
+	}
+	m.renderLog(reset)
+}
+
+func (m *runModel) renderLog(reset bool) {
+	plain, styled, shown := renderLog(m.entries, m.expanded)
+	m.shown = shown
+	if reset || !m.rightPane.HasDocument() {
+		m.rightPane.SetDocument(plain, styled)
+		return
+	}
+	m.rightPane.UpdateDocument(plain, styled)
+}
+
+// topEntry returns the entry shown at the top of the right pane.
+func (m runModel) topEntry() (int, bool) {
+	line := m.rightPane.Line() - 1
+	if line < 0 || line >= len(m.shown) {
+		return 0, false
+	}
+	return m.shown[line], true
+}
+
+// toggleGroup folds or unfolds the group at the top of the right pane.
+func (m *runModel) toggleGroup() {
+	i, ok := m.topEntry()
+	if !ok || m.entries[i].group < 0 {
+		return
+	}
+	group := m.entries[i].group
+	m.expanded[group] = !m.expanded[group]
+	m.renderLog(false)
+	m.scrollToEntry(group)
+}
+
+func (m *runModel) toggleAllGroups() {
+	expand := true
+	for _, open := range m.expanded {
+		if open {
+			expand = false
+			break
+		}
+	}
+	m.expanded = map[int]bool{}
+	for i, entry := range m.entries {
+		if entry.header && expand {
+			m.expanded[i] = true
+		}
+	}
+	if len(m.entries) > 0 {
+		m.renderLog(false)
+	}
+}
+
+// nextError scrolls to the next or previous error, unfolding its group.
+func (m *runModel) nextError(direction int) {
+	current, _ := m.topEntry()
+	var errors []int
+	for i, entry := range m.entries {
+		if entry.error {
+			errors = append(errors, i)
+		}
+	}
+	if len(errors) == 0 {
+		m.statusMsg = "No errors in this log."
+		return
+	}
+	target := -1
+	if direction > 0 {
+		for _, i := range errors {
+			if i > current {
+				target = i
+				break
+			}
+		}
+		if target < 0 {
+			target = errors[0]
+		}
+	} else {
+		for j := len(errors) - 1; j >= 0; j-- {
+			if errors[j] < current {
+				target = errors[j]
+				break
+			}
+		}
+		if target < 0 {
+			target = errors[len(errors)-1]
+		}
+	}
+	m.statusMsg = ""
+	if group := m.entries[target].group; group >= 0 && !m.expanded[group] {
+		m.expanded[group] = true
+		m.renderLog(false)
+	}
+	m.paneIndex = 1
+	m.scrollToEntry(target)
+}
+
+func (m *runModel) scrollToEntry(entry int) {
+	for line, i := range m.shown {
+		if i == entry {
+			m.rightPane.GotoLine(line + 1)
+			return
+		}
+	}
+}
+
+func (m runModel) owner() string {
+	return m.repository.GetOwner().GetLogin()
+}
+
+// poll loads the jobs again after delay, unless another poll was started
+// meanwhile.
+func (m runModel) poll(delay time.Duration) tea.Cmd {
+	generation := m.generation
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return pollMsg(generation)
+	})
+}
+
+// pollDelay doubles the refresh interval for every failed poll in a row, up
+// to maxPollInterval.
+func pollDelay(failures int) time.Duration {
+	delay := refreshInterval
+	for i := 0; i < failures && delay < maxPollInterval; i++ {
+		delay *= 2
+	}
+	if delay > maxPollInterval {
+		delay = maxPollInterval
+	}
+	return delay
+}
+
+func (m runModel) loadJobs() tea.Msg {
+	jobs, _, err := m.gh.Actions.ListWorkflowJobs(context.Background(), m.owner(), m.repository.GetName(), m.run.GetID(), &github.ListWorkflowJobsOptions{
+		Filter:      "latest",
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return jobsLoadedMsg{runID: m.run.GetID(), err: err}
+	}
+	return jobsLoadedMsg{runID: m.run.GetID(), jobs: jobs.Jobs}
+}
+
+// loadLog downloads the whole log of job from the URL GitHub redirects to.
+func (m runModel) loadLog(job *github.WorkflowJob) tea.Cmd {
+	final := job.GetStatus() == "completed"
+	return func() tea.Msg {
+		msg := jobLogLoadedMsg{jobID: job.GetID(), final: final}
+		u, _, err := m.gh.Actions.GetWorkflowJobLogs(context.Background(), m.owner(), m.repository.GetName(), job.GetID(), true)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		resp, err := http.Get(u.String())
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			msg.err = fmt.Errorf("unexpected response downloading the log: %s", resp.Status)
+			return msg
+		}
+		data, err := io.ReadAll(resp.Body)
+		msg.data, msg.err = string(data), err
+		return msg
+	}
+}
@@ -0,0 +1,172 @@
+package actions
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// ansiPattern matches the SGR escape sequences runners color their output
+// with.
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// logLine is a line of a job log with its timestamp split off.
+type logLine struct {
+	time time.Time
+	text string
+}
+
+// logEntry is a line of a job log as shown in the viewer.
+type logEntry struct {
+	text  string
+	plain string
+	// group is the index of the group header entry the line belongs to, or
+	// -1 outside of groups.
+	group  int
+	header bool
+	error  bool
+}
+
+// parseLog splits a job log into lines. Every line of a job log starts with
+// the time it was written, which is used to tell the steps apart.
+func parseLog(data string) []logLine {
+	data = strings.TrimSuffix(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	if data == "" {
+		return nil
+	}
+	var lines []logLine
+	var last time.Time
+	for _, line := range strings.Split(data, "\n") {
+		// Some runners prefix the log with a byte order mark.
+		line = strings.TrimPrefix(line, "\ufeff")
+		if stamp, text, ok := cutTimestamp(line); ok {
+			last = stamp
+			line = text
+		}
+		lines = append(lines, logLine{time: last, text: line})
+	}
+	return lines
+}
+
+func cutTimestamp(line string) (time.Time, string, bool) {
+	i := strings.IndexByte(line, ' ')
+	if i < 0 {
+		i = len(line)
+	}
+	stamp, err := time.Parse(time.RFC3339Nano, line[:i])
+	if err != nil {
+		return time.Time{}, line, false
+	}
+	if i < len(line) {
+		i++
+	}
+	return stamp, line[i:], true
+}
+
+// stepLines returns the lines written while step ran. Step times only have
+// second precision, so the whole last second is included.
+func stepLines(lines []logLine, step *github.TaskStep) []logLine {
+	if step == nil || step.StartedAt == nil {
+		return lines
+	}
+	start := step.GetStartedAt().Time
+	var end time.Time
+	if step.CompletedAt != nil {
+		end = step.GetCompletedAt().Add(time.Second)
+	}
+	var result []logLine
+	for _, line := range lines {
+		if line.time.Before(start) || (!end.IsZero() && !line.time.Before(end)) {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+// logEntries interprets the workflow commands in lines: groups and errors.
+// Both the "::" syntax of workflow commands and the "##[...]" syntax runners
+// write them to logs with are understood.
+func logEntries(lines []logLine) []logEntry {
+	entries := make([]logEntry, 0, len(lines))
+	group := -1
+	for _, line := range lines {
+		plain := ansiPattern.ReplaceAllString(line.text, "")
+		switch {
+		case hasCommand(plain, "group"):
+			group = len(entries)
+			title := commandMessage(plain, "group")
+			entries = append(entries, logEntry{text: title, plain: title, group: group, header: true})
+		case hasCommand(plain, "endgroup"):
+			group = -1
+		case hasCommand(plain, "error"):
+			message := "Error: " + commandMessage(plain, "error")
+			entries = append(entries, logEntry{text: message, plain: message, group: group, error: true})
+		default:
+			entries = append(entries, logEntry{text: line.text, plain: plain, group: group})
+		}
+	}
+	return entries
+}
+
+func hasCommand(line string, command string) bool {
+	return strings.HasPrefix(line, "##["+command+"]") ||
+		strings.HasPrefix(line, "::"+command+"::") ||
+		strings.HasPrefix(line, "::"+command+" ")
+}
+
+// commandMessage returns what follows a workflow command, dropping the
+// parameters of the "::command key=value::message" form.
+func commandMessage(line string, command string) string {
+	if strings.HasPrefix(line, "##["+command+"]") {
+		return strings.TrimPrefix(line, "##["+command+"]")
+	}
+	line = strings.TrimPrefix(line, "::"+command)
+	if i := strings.Index(line, "::"); i >= 0 {
+		return line[i+2:]
+	}
+	return line
+}
+
+// renderLog returns the visible lines of entries, leaving out the contents
+// of collapsed groups, both plain for searching and styled for display. It
+// also returns which entry every visible line shows.
+func renderLog(entries []logEntry, expanded map[int]bool) (string, string, []int) {
+	var plain, styled strings.Builder
+	var shown []int
+	for i, entry := range entries {
+		if !entry.header && entry.group >= 0 && !expanded[entry.group] {
+			continue
+		}
+		text, rawText := entry.text, entry.plain
+		switch {
+		case entry.header:
+			marker := "▸ "
+			if expanded[i] {
+				marker = "▾ "
+			}
+			rawText = marker + entry.plain
+			text = lipgloss.NewStyle().Bold(true).Render(rawText)
+		case entry.error:
+			text = common.ErrorStyle().Render(entry.plain)
+		}
+		if entry.group >= 0 && !entry.header {
+			text = "  " + text
+			rawText = "  " + rawText
+		}
+		if len(shown) > 0 {
+			plain.WriteString("\n")
+			styled.WriteString("\n")
+		}
+		plain.WriteString(rawText)
+		// Reset the colors a line leaves open so they don't bleed.
+		styled.WriteString(text + "\x1b[0m")
+		shown = append(shown, i)
+	}
+	return plain.String(), styled.String(), shown
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/browser"
+	"ghtui/ghtui/ui/common"
+)
+
+type checksLoadedMsg struct {
+	ref         string
+	state       string
+	items       []checkItem
+	annotations map[string][]*github.CheckRunAnnotation
+	err         error
+}
+
+// checkItem is a commit status or a check run of the current ref.
+type checkItem struct {
+	name        string
+	status      string
+	conclusion  string
+	description string
+	started     time.Time
+	completed   time.Time
+	url         string
+}
+
+// checksModel is the panel listing the checks of the current ref. The
+// annotations of the check runs are kept by path, to be shown next to the
+// lines of files they refer to.
+type checksModel struct {
+	open        bool
+	loaded      bool
+	ref         string
+	state       string
+	items       []checkItem
+	index       int
+	annotations map[string][]*github.CheckRunAnnotation
+	err         string
+}
+
+// loadChecks fetches the combined commit status and the check runs, with
+// their annotations, of the current ref.
+func (m Model) loadChecks() tea.Msg {
+	ctx := context.Background()
+	ref := m.ref
+	msg := checksLoadedMsg{ref: ref, annotations: map[string][]*github.CheckRunAnnotation{}}
+
+	combined, _, statusErr := m.gh.Repositories.GetCombinedStatus(ctx, m.owner(), *m.repository.Name, ref, &github.ListOptions{PerPage: 100})
+	if statusErr == nil {
+		msg.state = combined.GetState()
+		for _, status := range combined.Statuses {
+			item := checkItem{
+				name:        status.GetContext(),
+				status:      "completed",
+				conclusion:  status.GetState(),
+				description: status.GetDescription(),
+				started:     status.GetCreatedAt(),
+				completed:   status.GetUpdatedAt(),
+				url:         status.GetTargetURL(),
+			}
+			switch status.GetState() {
+			case "pending":
+				item.status = "in_progress"
+			case "error":
+				item.conclusion = "failure"
+			}
+			msg.items = append(msg.items, item)
+		}
+	}
+
+	runs, _, runsErr := m.gh.Checks.ListCheckRunsForRef(ctx, m.owner(), *m.repository.Name, ref, &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if runsErr == nil {
+		for _, run := range runs.CheckRuns {
+			url := run.GetDetailsURL()
+			if url == "" {
+				url = run.GetHTMLURL()
+			}
+			msg.items = append(msg.items, checkItem{
+				name:        run.GetName(),
+				status:      run.GetStatus(),
+				conclusion:  run.GetConclusion(),
+				description: run.GetOutput().GetTitle(),
+				started:     run.GetStartedAt().Time,
+				completed:   run.GetCompletedAt().Time,
+				url:         url,
+			})
+			if run.GetOutput().GetAnnotationsCount() == 0 {
+				continue
+			}
+			annotations, _, err := m.gh.Checks.ListCheckRunAnnotations(ctx, m.owner(), *m.repository.Name, run.GetID(), &github.ListOptions{PerPage: 100})
+			if err != nil {
+				continue
+			}
+			for _, annotation := range annotations {
+				msg.annotations[annotation.GetPath()] = append(msg.annotations[annotation.GetPath()], annotation)
+			}
+		}
+	}
+
+	if statusErr != nil && runsErr != nil {
+		msg.err = runsErr
+	}
+	return msg
+}
+
+func updateChecksLoaded(m Model, msg checksLoadedMsg) Model {
+	if msg.ref != m.ref {
+		return m
+	}
+	m.checks.loaded = true
+	m.checks.ref = msg.ref
+	m.checks.state = msg.state
+	m.checks.items = msg.items
+	m.checks.annotations = msg.annotations
+	m.checks.err = ""
+	if msg.err != nil {
+		m.checks.err = msg.err.Error()
+	}
+	if m.checks.index >= len(m.checks.items) {
+		m.checks.index = 0
+	}
+	// Show the annotations of a file that was opened before they arrived.
+	if m.file != nil && !m.checks.open && len(msg.annotations[m.file.content.GetPath()]) > 0 {
+		m = showFile(m, *m.file)
+	}
+	return m
+}
+
+func openChecks(m Model) (Model, tea.Cmd) {
+	m.checks.open = true
+	m.paneIndex = 1
+	if !m.checks.loaded {
+		return m, m.loadChecks
+	}
+	return m, nil
+}
+
+// closeChecks puts the file that was shown back into the right pane.
+func closeChecks(m Model) Model {
+	m.checks.open = false
+	if m.file != nil {
+		return showFile(m, *m.file)
+	}
+	m.paneIndex = 0
+	m.rightPane.SetContent("")
+	return m
+}
+
+func updateChecks(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "c":
+		return closeChecks(m), nil
+	case "up", "k":
+		if m.checks.index > 0 {
+			m.checks.index--
+		}
+	case "down", "j":
+		if m.checks.index < len(m.checks.items)-1 {
+			m.checks.index++
+		}
+	case "r":
+		m.checks.loaded = false
+		return m, m.loadChecks
+	case "enter", "o":
+		if m.checks.index < len(m.checks.items) {
+			if url := m.checks.items[m.checks.index].url; url != "" {
+				return m, browser.OpenCmd(m.config, url)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (c checksModel) View(width int) string {
+	if !c.loaded {
+		return "Loading checks..."
+	}
+	if c.err != "" {
+		return common.ErrorStyle().Render("Could not load the checks: " + c.err)
+	}
+	if len(c.items) == 0 {
+		return "There are no checks for " + c.ref + "."
+	}
+
+	failed, pending := 0, 0
+	for _, item := range c.items {
+		switch {
+		case item.status != "completed":
+			pending++
+		case item.conclusion == "failure" || item.conclusion == "timed_out" || item.conclusion == "action_required":
+			failed++
+		}
+	}
+	summary := fmt.Sprintf("Checks for %s: %d total, %d failing, %d pending", c.ref, len(c.items), failed, pending)
+	if c.state != "" {
+		summary += " · combined status " + c.state
+	}
+	lines := []string{summary, ""}
+	for i, item := range c.items {
+		duration := ""
+		if !item.started.IsZero() {
+			end := item.completed
+			if item.status != "completed" || end.IsZero() {
+				end = time.Now()
+			}
+			duration = common.FormatDuration(end.Sub(item.started))
+		}
+		conclusion := item.conclusion
+		if item.status != "completed" {
+			conclusion = strings.ReplaceAll(item.status, "_", " ")
+		}
+		line := fmt.Sprintf("%s %-30s %-12s %8s  %s",
+			common.StatusIcon(item.status, item.conclusion),
+			truncate.StringWithTail(item.name, 30, "…"),
+			conclusion,
+			duration,
+			item.description,
+		)
+		line = truncate.StringWithTail(line, uint(width), "…")
+		if i == c.index {
+			line = common.PaneSelectedItemStyle().Render(line)
+		}
+		lines = append(lines, line)
+		if i == c.index && item.url != "" {
+			lines = append(lines, lipgloss.NewStyle().Foreground(common.GrayColor()).Render("  "+item.url))
+		}
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Foreground(common.GrayColor()).Render("enter open details · r reload · esc close"))
+	return strings.Join(lines, "\n")
+}
+
+// annotate appends the annotations of a file to the lines they start at, in
+// both the raw and the rendered text, so the line numbers stay the same.
+func annotate(raw string, rendered string, annotations []*github.CheckRunAnnotation) (string, string) {
+	notes := map[int][]string{}
+	styled := map[int][]string{}
+	for _, annotation := range annotations {
+		line := annotation.GetStartLine()
+		message := annotation.GetMessage()
+		if i := strings.IndexByte(message, '\n'); i >= 0 {
+			message = message[:i]
+		}
+		if title := annotation.GetTitle(); title != "" {
+			message = title + ": " + message
+		}
+		note := "  ◀ " + annotation.GetAnnotationLevel() + ": " + message
+		style := lipgloss.NewStyle().Foreground(common.GrayColor())
+		switch annotation.GetAnnotationLevel() {
+		case "failure":
+			style = common.ErrorStyle()
+		case "warning":
+			style = lipgloss.NewStyle().Foreground(lipgloss.Color("#f2d0a9"))
+		}
+		notes[line] = append(notes[line], note)
+		styled[line] = append(styled[line], style.Render(note))
+	}
+	return appendNotes(raw, notes), appendNotes(rendered, styled)
+}
+
+func appendNotes(text string, notes map[int][]string) string {
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+	for i := range lines {
+		if n, ok := notes[i+1]; ok {
+			lines[i] += strings.Join(n, "")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
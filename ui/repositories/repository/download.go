@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// downloadTarget is what the download prompt saves. A nil content means the
+// whole repository as an archive.
+type downloadTarget struct {
+	content *github.RepositoryContent
+}
+
+// promptDownload asks where to save the target, suggesting a path in the
+// working directory.
+func promptDownload(m Model, target downloadTarget) (Model, tea.Cmd) {
+	if m.download.Active() {
+		m.statusMsg = "A download is already running."
+		return m, nil
+	}
+	var cmd tea.Cmd
+	switch content := target.content; {
+	case content == nil:
+		suggestion := *m.repository.Name + "-" + strings.ReplaceAll(m.ref, "/", "-") + ".tar.gz"
+		cmd = m.download.Prompt(*m.repository.Name+"@"+m.ref, suggestion, m.downloadArchive)
+	case content.GetType() == "dir":
+		cmd = m.download.Prompt(content.GetPath()+"/", content.GetName(), func(dest string, overwrite bool, p *common.DownloadProgress) error {
+			return m.downloadDirectory(content, dest, overwrite, p)
+		})
+	default:
+		cmd = m.download.Prompt(content.GetPath(), content.GetName(), func(dest string, overwrite bool, p *common.DownloadProgress) error {
+			p.TotalBytes = int64(content.GetSize())
+			return m.saveBlob(content.GetSHA(), dest, overwrite, p)
+		})
+	}
+	return m, cmd
+}
+
+// updateDownload passes key presses and progress to the download.
+func updateDownload(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var status string
+	m.download, cmd, status = m.download.Update(msg)
+	if status != "" {
+		m.statusMsg = status
+	}
+	return m, cmd
+}
+
+// downloadDirectory saves every file below dir, using the recursive tree of
+// the current ref to find them.
+func (m Model) downloadDirectory(dir *github.RepositoryContent, dest string, overwrite bool, p *common.DownloadProgress) error {
+	tree, _, err := m.gh.Git.GetTree(context.Background(), m.owner(), *m.repository.Name, m.ref, true)
+	if err != nil {
+		return err
+	}
+	if tree.GetTruncated() {
+		return fmt.Errorf("the file tree is too large and was truncated by GitHub; download the archive instead")
+	}
+
+	prefix := dir.GetPath() + "/"
+	var entries []*github.TreeEntry
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" && strings.HasPrefix(entry.GetPath(), prefix) {
+			entries = append(entries, entry)
+			p.TotalBytes += int64(entry.GetSize())
+		}
+	}
+	p.TotalFiles = len(entries)
+	p.Report()
+
+	for _, entry := range entries {
+		target := filepath.Join(dest, filepath.FromSlash(strings.TrimPrefix(entry.GetPath(), prefix)))
+		if err := m.saveBlob(entry.GetSHA(), target, overwrite, p); err != nil {
+			return err
+		}
+		p.Files++
+		p.Report()
+	}
+	return nil
+}
+
+// saveBlob streams the raw contents of a blob to dest, so the progress moves
+// as the bytes arrive.
+func (m Model) saveBlob(sha string, dest string, overwrite bool, p *common.DownloadProgress) error {
+	u := fmt.Sprintf("repos/%s/%s/git/blobs/%s", m.owner(), *m.repository.Name, sha)
+	req, err := m.gh.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3.raw")
+	resp, err := m.gh.BareDo(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return common.WriteFile(dest, overwrite, resp.Body, p)
+}
+
+// downloadArchive saves the repository at the current ref as a tarball, or as
+// a zipball when dest ends in .zip.
+func (m Model) downloadArchive(dest string, overwrite bool, p *common.DownloadProgress) error {
+	format := github.Tarball
+	if strings.HasSuffix(strings.ToLower(dest), ".zip") {
+		format = github.Zipball
+	}
+	link, _, err := m.gh.Repositories.GetArchiveLink(
+		context.Background(),
+		m.owner(),
+		*m.repository.Name,
+		format,
+		&github.RepositoryContentGetOptions{Ref: m.ref},
+		true,
+	)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Get(link.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response downloading the archive: %s", resp.Status)
+	}
+	if resp.ContentLength > 0 {
+		p.TotalBytes = resp.ContentLength
+	}
+	return common.WriteFile(dest, overwrite, resp.Body, p)
+}
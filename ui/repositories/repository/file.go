@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+const (
+	// largeFileSize is the size above which the contents API stops returning
+	// file contents and the blob API has to be used instead.
+	largeFileSize = 1024 * 1024
+	// highlightLimit is the size above which files are shown without syntax
+	// highlighting, which gets too slow for big inputs.
+	highlightLimit = 512 * 1024
+	// hexDumpLimit caps how much of a binary file is rendered as a hex dump.
+	hexDumpLimit = 64 * 1024
+	// binarySniffLength mirrors how much of a file git inspects to decide
+	// whether it is binary.
+	binarySniffLength = 8000
+	// lfsObjectLimit caps the size of the LFS objects fetched into the
+	// viewer.
+	lfsObjectLimit = 16 * 1024 * 1024
+
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+)
+
+type fileKind int
+
+const (
+	fileText fileKind = iota
+	fileBinary
+	fileLFSPointer
+)
+
+// lfsPointer describes a Git LFS pointer file checked in instead of the
+// actual object.
+type lfsPointer struct {
+	oid  string
+	size int64
+}
+
+// loadFile fetches the file at path for the current ref. Files larger than
+// the contents API limit are fetched as raw blobs.
+func (m Model) loadFile(path string) tea.Cmd {
+	return func() tea.Msg {
+		file, _, _, err := m.gh.Repositories.GetContents(
+			context.Background(),
+			m.owner(),
+			*m.repository.Name,
+			path,
+			&github.RepositoryContentGetOptions{
+				Ref: m.ref,
+			},
+		)
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+
+		var data []byte
+		if file.GetSize() > 0 && (file.GetEncoding() == "none" || file.Content == nil || *file.Content == "") {
+			data, _, err = m.gh.Git.GetBlobRaw(context.Background(), m.owner(), *m.repository.Name, file.GetSHA())
+		} else {
+			var content string
+			content, err = file.GetContent()
+			data = []byte(content)
+		}
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		return repositoryFileLoadedMsg{content: file, data: data}
+	}
+}
+
+// loadLFSObject downloads the object an LFS pointer file refers to. Objects
+// over lfsObjectLimit are not shown.
+func (m Model) loadLFSObject(file *github.RepositoryContent) tea.Cmd {
+	return func() tea.Msg {
+		u, err := lfsMediaURL(m.repository.GetHTMLURL(), m.ref, file.GetPath())
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		req, err := m.gh.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		resp, err := m.gh.BareDo(context.Background(), req)
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		defer resp.Body.Close()
+		tooLarge := fmt.Errorf("the LFS object of %s is over %s; press o to open it on GitHub", file.GetName(), common.FormatBytes(lfsObjectLimit))
+		if resp.ContentLength > lfsObjectLimit {
+			return repositoryErrorMsg(tooLarge)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, lfsObjectLimit+1))
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		if len(data) > lfsObjectLimit {
+			return repositoryErrorMsg(tooLarge)
+		}
+		return repositoryFileLoadedMsg{content: file, data: data, lfsObject: true}
+	}
+}
+
+// lfsMediaURL returns where the LFS object of the file at p is served for
+// the repository at htmlURL: GitHub's media host, or the /media path of an
+// enterprise host.
+func lfsMediaURL(htmlURL string, ref string, p string) (string, error) {
+	u, err := url.Parse(htmlURL)
+	if err != nil {
+		return "", err
+	}
+	media := "https://media.githubusercontent.com/media"
+	if !strings.EqualFold(u.Host, "github.com") {
+		media = u.Scheme + "://" + u.Host + "/media"
+	}
+	return media + u.EscapedPath() + "/" + escapePath(ref) + "/" + escapePath(p), nil
+}
+
+// classifyFile decides how a file's contents should be presented.
+func classifyFile(data []byte) (fileKind, *lfsPointer) {
+	if pointer, ok := parseLFSPointer(data); ok {
+		return fileLFSPointer, pointer
+	}
+	if isBinary(data) {
+		return fileBinary, nil
+	}
+	return fileText, nil
+}
+
+func isBinary(data []byte) bool {
+	sniff := data
+	if len(sniff) > binarySniffLength {
+		sniff = sniff[:binarySniffLength]
+		// Drop the last rune, which may have been cut off by the window.
+		for i := len(sniff) - 1; i >= 0 && i >= len(sniff)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(sniff[i]) {
+				sniff = sniff[:i]
+				break
+			}
+		}
+	}
+	return bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(sniff)
+}
+
+func parseLFSPointer(data []byte) (*lfsPointer, bool) {
+	// Pointer files are tiny; anything larger is real content.
+	if len(data) > 1024 || !bytes.HasPrefix(data, []byte(lfsPointerVersion)) {
+		return nil, false
+	}
+	pointer := &lfsPointer{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "oid":
+			pointer.oid = value
+		case "size":
+			pointer.size, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return pointer, pointer.oid != ""
+}
+
+// cut is strings.Cut, which is not available in Go 1.17.
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// showFile renders a loaded file in the right pane according to its kind.
+func showFile(m Model, msg repositoryFileLoadedMsg) Model {
+	file := msg.content
+	kind, pointer := classifyFile(msg.data)
+	if msg.lfsObject && kind == fileLFSPointer {
+		kind = fileText
+	}
+	m.selectedKind = kind
+
+	switch kind {
+	case fileLFSPointer:
+		m.rightPane.SetContent(fmt.Sprintf(
+			"%s is stored with Git LFS.\n\nOID:  %s\nSize: %s\n\nPress f to fetch the LFS object.",
+			file.GetName(), pointer.oid, common.FormatBytes(pointer.size),
+		))
+	case fileBinary:
+		dump := msg.data
+		s := fmt.Sprintf(
+			"%s is a binary file.\n\nSize: %s\nType: %s\nSHA:  %s\n\n",
+			file.GetName(), common.FormatBytes(int64(len(msg.data))), http.DetectContentType(msg.data), file.GetSHA(),
+		)
+		if len(dump) > hexDumpLimit {
+			dump = dump[:hexDumpLimit]
+			s += fmt.Sprintf("Showing the first %s.\n\n", common.FormatBytes(hexDumpLimit))
+		}
+		m.rightPane.SetContent(s + hex.Dump(dump))
+	default:
+		text := string(msg.data)
+		rendered := text
+		if len(msg.data) <= highlightLimit {
+			rendered = common.Highlight(file.GetName(), text)
+		}
+		if annotations := m.checks.annotations[file.GetPath()]; len(annotations) > 0 {
+			text, rendered = annotate(text, rendered, annotations)
+		}
+		m.rightPane.SetDocument(text, rendered)
+	}
+
+	if file.GetSize() > largeFileSize {
+		m.statusMsg = fmt.Sprintf("%s is %s, which is over the contents API limit; it was fetched as a raw blob.", file.GetName(), common.FormatBytes(int64(file.GetSize())))
+		if len(msg.data) > highlightLimit {
+			m.statusMsg += " Syntax highlighting is disabled."
+		}
+	} else if n := len(m.checks.annotations[file.GetPath()]); n > 0 && kind == fileText {
+		m.statusMsg = fmt.Sprintf("%d check annotations in %s; press c for the checks.", n, file.GetName())
+	} else {
+		m.statusMsg = ""
+	}
+	return m
+}
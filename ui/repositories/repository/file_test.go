@@ -0,0 +1,25 @@
+package repository
+
+import "testing"
+
+func TestLFSMediaURL(t *testing.T) {
+	tests := []struct {
+		htmlURL string
+		ref     string
+		path    string
+		want    string
+	}{
+		{"https://github.com/owner/repo", "main", "assets/logo.png", "https://media.githubusercontent.com/media/owner/repo/main/assets/logo.png"},
+		{"https://github.com/owner/repo", "feature/lfs", "a b/#1?.bin", "https://media.githubusercontent.com/media/owner/repo/feature/lfs/a%20b/%231%3F.bin"},
+		{"https://github.example.com/owner/repo", "main", "model.bin", "https://github.example.com/media/owner/repo/main/model.bin"},
+	}
+	for _, tt := range tests {
+		got, err := lfsMediaURL(tt.htmlURL, tt.ref, tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+}
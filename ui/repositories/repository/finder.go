@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"strings"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v39/github"
+	"github.com/sahilm/fuzzy"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// maxFinderResults caps the number of matches rendered by the finder.
+const maxFinderResults = 50
+
+type finderModel struct {
+	input   input.Model
+	paths   []string
+	matches fuzzy.Matches
+	index   int
+}
+
+func newFinderModel(entries []*github.TreeEntry) finderModel {
+	var paths []string
+	for _, entry := range entries {
+		if entry.GetType() == "blob" {
+			paths = append(paths, entry.GetPath())
+		}
+	}
+
+	inputModel := input.NewModel()
+	inputModel.Placeholder = "Find a file"
+	inputModel.Prompt = "> "
+	inputModel.Focus()
+
+	f := finderModel{
+		input: inputModel,
+		paths: paths,
+	}
+	f.filter()
+	return f
+}
+
+// filter refreshes the matches against the current input value.
+func (f *finderModel) filter() {
+	f.index = 0
+	query := strings.TrimSpace(f.input.Value())
+	if query == "" {
+		f.matches = make(fuzzy.Matches, len(f.paths))
+		for i, path := range f.paths {
+			f.matches[i] = fuzzy.Match{Str: path, Index: i}
+		}
+		return
+	}
+	f.matches = fuzzy.Find(query, f.paths)
+}
+
+// selected returns the currently highlighted path, if any.
+func (f finderModel) selected() (string, bool) {
+	if f.index < 0 || f.index >= len(f.matches) {
+		return "", false
+	}
+	return f.matches[f.index].Str, true
+}
+
+func (f finderModel) Update(msg tea.Msg) (finderModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "down", "ctrl+n":
+			if f.index < len(f.matches)-1 {
+				f.index += 1
+			}
+			return f, nil
+		case "up", "ctrl+p":
+			if f.index > 0 {
+				f.index -= 1
+			}
+			return f, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	previous := f.input.Value()
+	f.input, cmd = f.input.Update(msg)
+	if f.input.Value() != previous {
+		f.filter()
+	}
+	return f, cmd
+}
+
+func (f finderModel) View(height int) string {
+	s := f.input.View() + "\n\n"
+	limit := len(f.matches)
+	if limit > maxFinderResults {
+		limit = maxFinderResults
+	}
+	if height > 2 && limit > height-2 {
+		limit = height - 2
+	}
+
+	// Keep the selected match on screen when scrolling past the visible window.
+	offset := 0
+	if f.index >= limit {
+		offset = f.index - limit + 1
+	}
+	for i := offset; i < offset+limit && i < len(f.matches); i++ {
+		line := highlightMatch(f.matches[i])
+		if i == f.index {
+			line = common.PaneSelectedItemStyle().Render(f.matches[i].Str)
+		}
+		s += line + "\n"
+	}
+	if len(f.matches) == 0 {
+		s += "No matching files."
+	}
+	return s
+}
+
+func highlightMatch(match fuzzy.Match) string {
+	if len(match.MatchedIndexes) == 0 {
+		return match.Str
+	}
+	matched := make(map[int]bool, len(match.MatchedIndexes))
+	for _, i := range match.MatchedIndexes {
+		matched[i] = true
+	}
+	var b strings.Builder
+	for i, r := range match.Str {
+		if matched[i] {
+			b.WriteString(common.MatchStyle().Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/git"
+	"ghtui/ghtui/ui/common"
+)
+
+// checkoutPull fetches the head of a pull request into a local branch of the
+// clone ghtui runs in. A branch from a fork is named after its owner, so it
+// doesn't clash with the branches of the repository.
+func (m Model) checkoutPull(number int) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := os.Getwd()
+		if err != nil {
+			return statusMsg("Could not get the working directory: " + err.Error())
+		}
+		checkout, ok := git.DetectCheckout(dir, m.config.Host)
+		if !ok || !strings.EqualFold(checkout.Owner, m.owner()) || !strings.EqualFold(checkout.Name, m.repository.GetName()) {
+			return statusMsg(fmt.Sprintf("ghtui isn't running inside a clone of %s/%s.", m.owner(), m.repository.GetName()))
+		}
+		pull, _, err := m.gh.PullRequests.Get(context.Background(), m.owner(), m.repository.GetName(), number)
+		if err != nil {
+			return statusMsg(fmt.Sprintf("Could not load #%d: %s", number, err))
+		}
+
+		head := git.PullHead{Number: number, Ref: pull.GetHead().GetRef()}
+		branch := head.Ref
+		switch fork := pull.GetHead().GetRepo(); {
+		case fork == nil:
+			head.Ref = ""
+			branch = fmt.Sprintf("pr-%d", number)
+		case fork.GetID() != pull.GetBase().GetRepo().GetID():
+			head.URL = forkURL(checkout.Dir, fork)
+			branch = fork.GetOwner().GetLogin() + "-" + head.Ref
+		}
+
+		result, err := git.CheckoutPull(checkout.Dir, "origin", head, branch)
+		var dirty *git.DirtyError
+		var conflict *git.ConflictError
+		switch {
+		case errors.As(err, &dirty):
+			return statusMsg(fmt.Sprintf("Commit or stash your changes before checking out #%d: %s", number, strings.Join(dirty.Files, ", ")))
+		case errors.As(err, &conflict):
+			return statusMsg(fmt.Sprintf("Could not check out #%d into %s: %s", number, conflict.Branch, conflict.Reason))
+		case err != nil:
+			return statusMsg(fmt.Sprintf("Could not check out #%d: %s", number, err))
+		case result.Created:
+			return statusMsg(fmt.Sprintf("Checked out #%d into the new branch %s.", number, result.Branch))
+		}
+		return statusMsg(fmt.Sprintf("Checked out #%d into %s at %s.", number, result.Branch, common.ShortSHA(result.SHA)))
+	}
+}
+
+// forkURL is the URL to fetch a fork from, over SSH when the clone uses it.
+func forkURL(dir string, fork *github.Repository) string {
+	origin, err := git.RemoteURL(dir, "origin")
+	if err == nil && !strings.HasPrefix(origin, "http") && fork.GetSSHURL() != "" {
+		return fork.GetSSHURL()
+	}
+	return fork.GetCloneURL()
+}
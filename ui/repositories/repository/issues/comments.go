@@ -0,0 +1,239 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+type commentsLoadedMsg struct {
+	number   int
+	comments []*github.IssueComment
+}
+
+// commentWrittenMsg carries a comment back from the editor. comment is the
+// comment being edited, or nil for a new one.
+type commentWrittenMsg struct {
+	number  int
+	comment *github.IssueComment
+	text    string
+	err     error
+}
+
+// changedMsg reports that an issue or its comments changed, so they are
+// fetched again.
+type changedMsg struct {
+	number int
+	status string
+}
+
+// reactions are the reactions GitHub offers, in the order it shows them.
+var reactions = []struct {
+	content string
+	emoji   string
+	count   func(r *github.Reactions) int
+}{
+	{"+1", "👍", (*github.Reactions).GetPlusOne},
+	{"-1", "👎", (*github.Reactions).GetMinusOne},
+	{"laugh", "😄", (*github.Reactions).GetLaugh},
+	{"hooray", "🎉", (*github.Reactions).GetHooray},
+	{"confused", "😕", (*github.Reactions).GetConfused},
+	{"heart", "❤️", (*github.Reactions).GetHeart},
+	{"rocket", "🚀", (*github.Reactions).GetRocket},
+	{"eyes", "👀", (*github.Reactions).GetEyes},
+}
+
+// closeReasons are the reasons an issue can be closed for.
+var closeReasons = []option{
+	{value: "completed", label: "Completed", description: "done, closed or fixed"},
+	{value: "not_planned", label: "Not planned", description: "won't fix, can't repro, duplicate or stale"},
+	{value: "none", label: "No reason"},
+}
+
+// loadComments fetches all the comments of an issue.
+func (m Model) loadComments(number int) tea.Cmd {
+	return func() tea.Msg {
+		opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+		var comments []*github.IssueComment
+		for {
+			page, resp, err := m.gh.Issues.ListComments(context.Background(), m.owner(), m.repository.GetName(), number, opts)
+			if err != nil {
+				return statusMsg("Could not load the comments: " + err.Error())
+			}
+			comments = append(comments, page...)
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+		return commentsLoadedMsg{number: number, comments: comments}
+	}
+}
+
+// reloadIssue fetches an issue and its comments again after a change.
+func (m Model) reloadIssue(number int) tea.Cmd {
+	return tea.Batch(m.loadIssue(number), m.loadComments(number))
+}
+
+// own reports whether comment was written by the signed in user.
+func (m Model) own(comment *github.IssueComment) bool {
+	return comment.GetUser().GetLogin() == m.user.GetLogin()
+}
+
+// writeComment writes a new comment, or edits comment, in the editor.
+func (m Model) writeComment(comment *github.IssueComment) tea.Cmd {
+	number := m.issue.issue.GetNumber()
+	return common.Edit("comment.md", comment.GetBody(), func(text string, err error) tea.Msg {
+		return commentWrittenMsg{number: number, comment: comment, text: text, err: err}
+	})
+}
+
+func updateCommentWritten(m Model, msg commentWrittenMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.statusMsg = "Could not edit the comment: " + msg.err.Error()
+		return m, nil
+	}
+	if strings.TrimSpace(msg.text) == "" {
+		m.statusMsg = "The comment was left empty, so nothing was saved."
+		return m, nil
+	}
+	if msg.comment != nil && msg.text == msg.comment.GetBody() {
+		m.statusMsg = "No changes to the comment."
+		return m, nil
+	}
+	m.statusMsg = "Saving the comment..."
+	return m, m.saveComment(msg.number, msg.comment, msg.text)
+}
+
+func (m Model) saveComment(number int, comment *github.IssueComment, text string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		owner, name := m.owner(), m.repository.GetName()
+		if comment == nil {
+			if _, _, err := m.gh.Issues.CreateComment(ctx, owner, name, number, &github.IssueComment{Body: github.String(text)}); err != nil {
+				return statusMsg("Could not comment: " + err.Error())
+			}
+			return changedMsg{number: number, status: "Commented."}
+		}
+		if _, _, err := m.gh.Issues.EditComment(ctx, owner, name, comment.GetID(), &github.IssueComment{Body: github.String(text)}); err != nil {
+			return statusMsg("Could not save the comment: " + err.Error())
+		}
+		return changedMsg{number: number, status: "Saved the comment."}
+	}
+}
+
+func (m Model) deleteComment(number int, comment *github.IssueComment) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.gh.Issues.DeleteComment(context.Background(), m.owner(), m.repository.GetName(), comment.GetID()); err != nil {
+			return statusMsg("Could not delete the comment: " + err.Error())
+		}
+		return changedMsg{number: number, status: "Deleted the comment."}
+	}
+}
+
+// openReactions asks for a reaction to the selected issue or comment.
+func (m Model) openReactions() {
+	var options []option
+	for _, r := range reactions {
+		options = append(options, option{value: r.content, label: r.emoji + " " + r.content})
+	}
+	p := newPicker("React with", options, nil, false)
+	view := m.issue
+	view.picker = &p
+	number := view.issue.GetNumber()
+	comment, onComment := view.comment()
+	view.choose = func(values []string) tea.Cmd {
+		return func() tea.Msg {
+			ctx := context.Background()
+			var err error
+			if onComment {
+				_, _, err = m.gh.Reactions.CreateIssueCommentReaction(ctx, m.owner(), m.repository.GetName(), comment.GetID(), values[0])
+			} else {
+				_, _, err = m.gh.Reactions.CreateIssueReaction(ctx, m.owner(), m.repository.GetName(), number, values[0])
+			}
+			if err != nil {
+				return statusMsg("Could not react: " + err.Error())
+			}
+			return changedMsg{number: number, status: "Reacted with " + values[0] + "."}
+		}
+	}
+}
+
+// reactionsLine sums up the reactions to an issue or a comment.
+func reactionsLine(r *github.Reactions) string {
+	var counts []string
+	for _, reaction := range reactions {
+		if n := reaction.count(r); n > 0 {
+			counts = append(counts, fmt.Sprintf("%s %d", reaction.emoji, n))
+		}
+	}
+	return strings.Join(counts, "  ")
+}
+
+// toggleState reopens a closed issue, or closes an open one. An issue is
+// closed with a reason, which pull requests don't have.
+func (m Model) toggleState() (Model, tea.Cmd) {
+	view := m.issue
+	issue := view.issue
+	number := issue.GetNumber()
+	if issue.GetState() == "closed" {
+		view.confirm = &confirmation{
+			prompt: fmt.Sprintf("Reopen #%d? (y/n)", number),
+			action: m.setState(number, "open", ""),
+		}
+		return m, nil
+	}
+	if m.pulls {
+		view.confirm = &confirmation{
+			prompt: fmt.Sprintf("Close #%d? (y/n)", number),
+			action: m.setState(number, "closed", ""),
+		}
+		return m, nil
+	}
+	p := newPicker(fmt.Sprintf("Close #%d as", number), closeReasons, nil, false)
+	view.picker = &p
+	view.choose = func(values []string) tea.Cmd {
+		reason := values[0]
+		if reason == "none" {
+			reason = ""
+		}
+		return m.setState(number, "closed", reason)
+	}
+	return m, nil
+}
+
+// setState closes or reopens an issue. The request is made by hand, as
+// go-github doesn't know about the reason yet.
+func (m Model) setState(number int, state string, reason string) tea.Cmd {
+	return func() tea.Msg {
+		body := map[string]interface{}{"state": state}
+		if reason != "" {
+			body["state_reason"] = reason
+		}
+		endpoint := fmt.Sprintf("repos/%s/%s/issues/%d", url.PathEscape(m.owner()), url.PathEscape(m.repository.GetName()), number)
+		req, err := m.gh.NewRequest(http.MethodPatch, endpoint, body)
+		if err == nil {
+			_, err = m.gh.Do(context.Background(), req, nil)
+		}
+		if err != nil {
+			if state == "open" {
+				return statusMsg(fmt.Sprintf("Could not reopen #%d: %s", number, err))
+			}
+			return statusMsg(fmt.Sprintf("Could not close #%d: %s", number, err))
+		}
+		if state == "open" {
+			return changedMsg{number: number, status: fmt.Sprintf("Reopened #%d.", number)}
+		}
+		if reason == "not_planned" {
+			return changedMsg{number: number, status: fmt.Sprintf("Closed #%d as not planned.", number)}
+		}
+		return changedMsg{number: number, status: fmt.Sprintf("Closed #%d.", number)}
+	}
+}
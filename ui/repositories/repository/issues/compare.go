@@ -0,0 +1,573 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// pullTemplateName is the name of the pull request template, in any case.
+const pullTemplateName = "pull_request_template.md"
+
+// pullTemplateDirs are where GitHub looks for the pull request template, in
+// the order it looks.
+var pullTemplateDirs = []string{".github", "", "docs"}
+
+// pullOptionsLoadedMsg carries what the pull request form offers to choose
+// from.
+type pullOptionsLoadedMsg struct {
+	branches  []*github.Branch
+	labels    []*github.Label
+	reviewers []*github.User
+	template  string
+	err       error
+}
+
+type comparedMsg struct {
+	base       string
+	head       string
+	comparison *github.CommitsComparison
+	err        error
+}
+
+type pullCreatedMsg struct {
+	issue  *github.Issue
+	status string
+	err    error
+}
+
+// The fields of the pull request form, in the order they are shown.
+const (
+	pullFieldBase = iota
+	pullFieldHead
+	pullFieldTitle
+	pullFieldBody
+	pullFieldReviewers
+	pullFieldLabels
+	pullFieldDraft
+	pullFieldCount
+)
+
+// pullForm opens a pull request from a head branch into a base branch. The
+// changes between them are shown under the form, and the title and body are
+// written from their commits until they are changed.
+type pullForm struct {
+	options     *pullOptionsLoadedMsg
+	base        string
+	head        string
+	comparison  *github.CommitsComparison
+	comparing   bool
+	title       input.Model
+	titleEdited bool
+	body        string
+	bodyEdited  bool
+	reviewers   []string
+	labels      []string
+	draft       bool
+	focus       int
+	picker      *picker
+	changes     viewport.Model
+	saving      bool
+	err         string
+}
+
+func (m Model) openPullForm() (Model, tea.Cmd) {
+	form := &pullForm{base: m.repository.GetDefaultBranch()}
+	form.title = input.NewModel()
+	form.title.Prompt = ""
+	form.title.Placeholder = "Title"
+	form.resize(m.width, m.height)
+	form.setFocus(pullFieldHead)
+	m.pullForm = form
+	m.statusMsg = "Loading branches..."
+	return m, m.loadPullOptions
+}
+
+func (f *pullForm) resize(width int, height int) {
+	f.changes.Width = width
+	f.changes.Height = height - 18
+	if f.changes.Height < 3 {
+		f.changes.Height = 3
+	}
+	f.render()
+}
+
+func (f *pullForm) setFocus(focus int) {
+	f.focus = focus
+	if focus == pullFieldTitle {
+		f.title.Focus()
+	} else {
+		f.title.Blur()
+	}
+}
+
+// setOptions fills in the pickers, and asks for the head branch.
+func (f *pullForm) setOptions(options pullOptionsLoadedMsg) {
+	f.options = &options
+	if f.head == "" {
+		p := f.newPicker()
+		f.picker = &p
+	}
+}
+
+func (f *pullForm) setBody(msg bodyEditedMsg) {
+	if msg.err != nil {
+		f.err = "Could not edit the description: " + msg.err.Error()
+		return
+	}
+	f.err = ""
+	f.body = msg.text
+	f.bodyEdited = true
+}
+
+// setComparison shows the changes between the branches, and writes the title
+// and the body from them unless they were changed.
+func (f *pullForm) setComparison(msg comparedMsg) {
+	if msg.base != f.base || msg.head != f.head {
+		return
+	}
+	f.comparing = false
+	if msg.err != nil {
+		f.comparison = nil
+		f.err = "Could not compare the branches: " + msg.err.Error()
+		f.render()
+		return
+	}
+	f.err = ""
+	f.comparison = msg.comparison
+	commits := msg.comparison.Commits
+
+	if !f.titleEdited {
+		title := branchTitle(f.head)
+		if len(commits) == 1 {
+			title, _ = splitMessage(commits[0].GetCommit().GetMessage())
+		}
+		f.title.SetValue(title)
+		f.title.CursorEnd()
+	}
+	if !f.bodyEdited {
+		switch {
+		case f.options != nil && f.options.template != "":
+			f.body = f.options.template
+		case len(commits) == 1:
+			_, f.body = splitMessage(commits[0].GetCommit().GetMessage())
+		default:
+			var lines []string
+			for _, commit := range commits {
+				subject, _ := splitMessage(commit.GetCommit().GetMessage())
+				lines = append(lines, "- "+subject)
+			}
+			f.body = strings.Join(lines, "\n")
+		}
+	}
+	f.render()
+}
+
+// render lists the commits and the diff between the branches.
+func (f *pullForm) render() {
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	var lines []string
+	switch {
+	case f.head == "":
+		lines = append(lines, gray.Render("Choose a branch to compare."))
+	case f.comparing:
+		lines = append(lines, gray.Render("Comparing "+f.base+"..."+f.head+"..."))
+	case f.comparison == nil:
+	case f.comparison.GetAheadBy() == 0:
+		lines = append(lines, gray.Render(f.head+" has no commits that aren't in "+f.base+"."))
+	default:
+		for _, commit := range f.comparison.Commits {
+			subject, _ := splitMessage(commit.GetCommit().GetMessage())
+			line := common.ShortSHA(commit.GetSHA()) + " " + subject + gray.Render(" · "+commitAuthor(commit))
+			lines = append(lines, truncate.StringWithTail(line, uint(f.changes.Width), "…"))
+		}
+		var diff []string
+		for _, file := range f.comparison.Files {
+			diff = append(diff, fmt.Sprintf("--- a/%s\n+++ b/%s", file.GetFilename(), file.GetFilename()))
+			if file.GetPatch() == "" {
+				diff = append(diff, "Binary or too large to show.")
+			} else {
+				diff = append(diff, file.GetPatch())
+			}
+		}
+		lines = append(lines, "", common.Highlight("changes.diff", strings.Join(diff, "\n")))
+	}
+	f.changes.SetContent(strings.Join(lines, "\n"))
+}
+
+func updatePullForm(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	form := m.pullForm
+	if form.saving {
+		return m, nil
+	}
+	if form.picker != nil {
+		done, cmd := form.picker.update(msg)
+		if !done {
+			return m, cmd
+		}
+		p := *form.picker
+		form.picker = nil
+		return m, form.choose(m, p)
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.pullForm = nil
+		m.statusMsg = ""
+		return m, nil
+	case "tab", "down":
+		form.setFocus((form.focus + 1) % pullFieldCount)
+		return m, input.Blink
+	case "shift+tab", "up":
+		form.setFocus((form.focus + pullFieldCount - 1) % pullFieldCount)
+		return m, input.Blink
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		form.changes, cmd = form.changes.Update(msg)
+		return m, cmd
+	case "ctrl+s":
+		return m.submitPullForm()
+	}
+
+	switch form.focus {
+	case pullFieldTitle:
+		if msg.Type == tea.KeyEnter {
+			form.setFocus(pullFieldBody)
+			return m, nil
+		}
+		title := form.title.Value()
+		var cmd tea.Cmd
+		form.title, cmd = form.title.Update(msg)
+		if form.title.Value() != title {
+			form.titleEdited = true
+		}
+		return m, cmd
+	case pullFieldBody:
+		if msg.String() == "enter" || msg.String() == "e" {
+			return m, common.Edit("pull_request.md", form.body, func(text string, err error) tea.Msg {
+				return bodyEditedMsg{text: text, err: err}
+			})
+		}
+	case pullFieldDraft:
+		if msg.String() == "enter" || msg.String() == " " || msg.String() == "x" {
+			form.draft = !form.draft
+		}
+	default:
+		if msg.String() != "enter" && msg.String() != " " {
+			return m, nil
+		}
+		if form.options == nil {
+			form.err = "Still loading the branches, reviewers and labels."
+			return m, nil
+		}
+		form.err = ""
+		p := form.newPicker()
+		form.picker = &p
+		return m, input.Blink
+	}
+	return m, nil
+}
+
+// newPicker opens the picker of the focused field.
+func (f pullForm) newPicker() picker {
+	switch f.focus {
+	case pullFieldReviewers:
+		var options []option
+		for _, user := range f.options.reviewers {
+			options = append(options, option{value: user.GetLogin(), label: user.GetLogin()})
+		}
+		return newPicker("Reviewers", sortedOptions(options), f.reviewers, true)
+	case pullFieldLabels:
+		var options []option
+		for _, label := range f.options.labels {
+			options = append(options, option{value: label.GetName(), label: label.GetName(), description: label.GetDescription()})
+		}
+		return newPicker("Labels", sortedOptions(options), f.labels, true)
+	default:
+		var options []option
+		for _, branch := range f.options.branches {
+			option := option{value: branch.GetName(), label: branch.GetName()}
+			if branch.GetProtected() {
+				option.description = "protected"
+			}
+			options = append(options, option)
+		}
+		if f.focus == pullFieldBase {
+			return newPicker("Base branch", options, []string{f.base}, false)
+		}
+		return newPicker("Head branch", options, []string{f.head}, false)
+	}
+}
+
+// choose takes the choice of a closed picker, and compares the branches
+// again when one of them changed.
+func (f *pullForm) choose(m Model, p picker) tea.Cmd {
+	switch f.focus {
+	case pullFieldReviewers:
+		f.reviewers = p.values()
+	case pullFieldLabels:
+		f.labels = p.values()
+	case pullFieldBase, pullFieldHead:
+		values := p.values()
+		if len(values) == 0 || values[0] == f.base && f.focus == pullFieldBase || values[0] == f.head && f.focus == pullFieldHead {
+			return nil
+		}
+		if f.focus == pullFieldBase {
+			f.base = values[0]
+		} else {
+			f.head = values[0]
+			f.setFocus(pullFieldTitle)
+		}
+		if f.head == "" {
+			return nil
+		}
+		if f.head == f.base {
+			f.comparison = nil
+			f.err = "choose two different branches"
+			f.render()
+			return nil
+		}
+		f.err = ""
+		f.comparing = true
+		f.render()
+		return tea.Batch(input.Blink, m.compare(f.base, f.head))
+	}
+	return nil
+}
+
+func (f pullForm) View() string {
+	if f.picker != nil {
+		return f.picker.View()
+	}
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	field := func(focus int, label string, value string) string {
+		return common.FormField(f.focus == focus, label, value)
+	}
+	list := func(values []string) string {
+		if len(values) == 0 {
+			return gray.Render("none")
+		}
+		return strings.Join(values, ", ")
+	}
+	head := f.head
+	if head == "" {
+		head = gray.Render("choose a branch")
+	}
+	body := gray.Render("empty; press enter to write it in $EDITOR")
+	if strings.TrimSpace(f.body) != "" {
+		first := strings.SplitN(strings.TrimSpace(f.body), "\n", 2)[0]
+		body = truncate.StringWithTail(first, 60, "…") + gray.Render("  "+common.Plural(strings.Count(strings.TrimRight(f.body, "\n"), "\n")+1, "line"))
+	}
+
+	lines := []string{
+		"New pull request",
+		"",
+		field(pullFieldBase, "Base", f.base),
+		field(pullFieldHead, "Head", head),
+		field(pullFieldTitle, "Title *", f.title.View()),
+		field(pullFieldBody, "Description", body),
+		field(pullFieldReviewers, "Reviewers", list(f.reviewers)),
+		field(pullFieldLabels, "Labels", list(f.labels)),
+		common.FormCursor(f.focus == pullFieldDraft) + common.Checkbox(f.draft) + " Draft",
+		"",
+	}
+	box := common.FormView(lines, f.err, "tab/↑/↓ move · enter edit or choose · pgup/pgdown scroll changes · ctrl+s create · esc cancel")
+
+	summary := ""
+	if c := f.comparison; c != nil && c.GetAheadBy() > 0 {
+		additions, deletions := 0, 0
+		for _, file := range c.Files {
+			additions += file.GetAdditions()
+			deletions += file.GetDeletions()
+		}
+		summary = gray.Render(fmt.Sprintf("%s · %s changed · +%d −%d",
+			common.Plural(c.GetAheadBy(), "commit"), common.Plural(len(c.Files), "file"), additions, deletions))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, box, summary, f.changes.View())
+}
+
+func (m Model) submitPullForm() (Model, tea.Cmd) {
+	form := m.pullForm
+	title := strings.TrimSpace(form.title.Value())
+	switch {
+	case form.head == "":
+		form.err = "a head branch is required"
+		return m, nil
+	case form.head == form.base:
+		form.err = "choose two different branches"
+		return m, nil
+	case form.comparing:
+		form.err = "still comparing the branches"
+		return m, nil
+	case form.comparison != nil && form.comparison.GetAheadBy() == 0:
+		form.err = form.head + " has nothing to merge into " + form.base
+		return m, nil
+	case title == "":
+		form.err = "a title is required"
+		return m, nil
+	}
+	form.saving = true
+	form.err = ""
+	m.statusMsg = "Creating the pull request..."
+	return m, m.createPull(&github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(form.head),
+		Base:  github.String(form.base),
+		Body:  github.String(form.body),
+		Draft: github.Bool(form.draft),
+	}, form.reviewers, form.labels)
+}
+
+// createPull opens a pull request, then asks for reviews and adds labels,
+// which can't be given when creating it.
+func (m Model) createPull(pull *github.NewPullRequest, reviewers []string, labels []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		owner, name := m.owner(), m.repository.GetName()
+		created, _, err := m.gh.PullRequests.Create(ctx, owner, name, pull)
+		if err != nil {
+			return pullCreatedMsg{err: err}
+		}
+		number := created.GetNumber()
+		status := fmt.Sprintf("Created #%d.", number)
+		if len(reviewers) > 0 {
+			if _, _, err := m.gh.PullRequests.RequestReviewers(ctx, owner, name, number, github.ReviewersRequest{Reviewers: reviewers}); err != nil {
+				status = fmt.Sprintf("Created #%d, but could not request reviews: %s", number, err)
+			}
+		}
+		if len(labels) > 0 {
+			if _, _, err := m.gh.Issues.AddLabelsToIssue(ctx, owner, name, number, labels); err != nil {
+				status = fmt.Sprintf("Created #%d, but could not add the labels: %s", number, err)
+			}
+		}
+		issue, _, err := m.gh.Issues.Get(ctx, owner, name, number)
+		if err != nil {
+			return pullCreatedMsg{status: fmt.Sprintf("Created #%d, but could not load it: %s", number, err)}
+		}
+		return pullCreatedMsg{issue: issue, status: status}
+	}
+}
+
+// updatePullCreated shows the new pull request, or the error in the form so
+// nothing written is lost.
+func updatePullCreated(m Model, msg pullCreatedMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.statusMsg = ""
+		if m.pullForm != nil {
+			m.pullForm.saving = false
+			m.pullForm.err = "Could not create the pull request: " + msg.err.Error()
+		}
+		return m, nil
+	}
+	m.pullForm = nil
+	if msg.issue == nil {
+		m, cmd := m.reload()
+		m.statusMsg = msg.status
+		return m, cmd
+	}
+	if states[m.state] != "closed" {
+		m.issues = append([]*github.Issue{msg.issue}, m.issues...)
+		m.index = 0
+		m.move(0)
+	}
+	m, cmd := m.openIssue(msg.issue)
+	m.statusMsg = msg.status
+	return m, cmd
+}
+
+// loadPullOptions fetches the branches, labels, possible reviewers and the
+// pull request template of the repository.
+func (m Model) loadPullOptions() tea.Msg {
+	var msg pullOptionsLoadedMsg
+	if msg.branches, msg.err = m.listBranches(); msg.err != nil {
+		return msg
+	}
+	if msg.labels, msg.err = m.listLabels(); msg.err != nil {
+		return msg
+	}
+	if msg.reviewers, msg.err = m.listAssignees(); msg.err != nil {
+		return msg
+	}
+	// GitHub won't ask the author to review their own pull request.
+	for i, user := range msg.reviewers {
+		if user.GetLogin() == m.user.GetLogin() {
+			msg.reviewers = append(msg.reviewers[:i], msg.reviewers[i+1:]...)
+			break
+		}
+	}
+	msg.template, msg.err = m.loadPullTemplate()
+	return msg
+}
+
+// loadPullTemplate fetches the pull request template of the repository, or
+// nothing if it has none.
+func (m Model) loadPullTemplate() (string, error) {
+	ctx := context.Background()
+	owner, name := m.owner(), m.repository.GetName()
+	for _, dir := range pullTemplateDirs {
+		_, entries, resp, err := m.gh.Repositories.GetContents(ctx, owner, name, dir, nil)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return "", err
+		}
+		for _, entry := range entries {
+			if entry.GetType() != "file" || !strings.EqualFold(entry.GetName(), pullTemplateName) {
+				continue
+			}
+			file, _, _, err := m.gh.Repositories.GetContents(ctx, owner, name, entry.GetPath(), nil)
+			if err != nil {
+				return "", err
+			}
+			return file.GetContent()
+		}
+	}
+	return "", nil
+}
+
+func (m Model) compare(base string, head string) tea.Cmd {
+	return func() tea.Msg {
+		comparison, _, err := m.gh.Repositories.CompareCommits(context.Background(), m.owner(), m.repository.GetName(), base, head, nil)
+		return comparedMsg{base: base, head: head, comparison: comparison, err: err}
+	}
+}
+
+// splitMessage splits a commit message into its subject and its body.
+func splitMessage(message string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
+// branchTitle turns a branch name into a title, the way GitHub does when
+// the branch has several commits.
+func branchTitle(branch string) string {
+	title := strings.NewReplacer("-", " ", "_", " ").Replace(branch)
+	first, size := utf8.DecodeRuneInString(title)
+	if size == 0 {
+		return title
+	}
+	return string(unicode.ToUpper(first)) + title[size:]
+}
+
+func commitAuthor(commit *github.RepositoryCommit) string {
+	if login := commit.GetAuthor().GetLogin(); login != "" {
+		return login
+	}
+	return commit.GetCommit().GetAuthor().GetName()
+}
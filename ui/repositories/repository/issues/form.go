@@ -0,0 +1,462 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// bodyEditedMsg carries the description of an issue back from the editor.
+type bodyEditedMsg struct {
+	text string
+	err  error
+}
+
+type issueSavedMsg struct {
+	issue   *github.Issue
+	created bool
+	err     error
+}
+
+// The fields of the issue form, in the order they are shown.
+const (
+	fieldTemplate = iota
+	fieldTitle
+	fieldBody
+	fieldLabels
+	fieldAssignees
+	fieldMilestone
+	fieldCount
+)
+
+// issueForm writes a new issue, or changes an existing one. The description
+// is written in the user's editor.
+type issueForm struct {
+	// issue is the issue being edited, or nil for a new one.
+	issue   *github.Issue
+	options *optionsLoadedMsg
+	// template is 0 for a blank issue and i+1 for options.templates[i].
+	template  int
+	title     input.Model
+	body      string
+	labels    []string
+	assignees []string
+	milestone int
+	focus     int
+	picker    *picker
+	saving    bool
+	err       string
+}
+
+// openForm starts a new issue, or edits issue when it is not nil. The
+// choices of the pickers are loaded the first time.
+func (m Model) openForm(issue *github.Issue) (Model, tea.Cmd) {
+	form := &issueForm{issue: issue}
+	form.title = input.NewModel()
+	form.title.Prompt = ""
+	form.title.Placeholder = "Title"
+	if issue != nil {
+		form.title.SetValue(issue.GetTitle())
+		form.title.CursorEnd()
+		form.body = issue.GetBody()
+		for _, label := range issue.Labels {
+			form.labels = append(form.labels, label.GetName())
+		}
+		for _, assignee := range issue.Assignees {
+			form.assignees = append(form.assignees, assignee.GetLogin())
+		}
+		form.milestone = issue.GetMilestone().GetNumber()
+	}
+	form.setFocus(fieldTitle)
+	m.form = form
+	m.statusMsg = ""
+	if m.options == nil {
+		return m, tea.Batch(input.Blink, m.loadOptions)
+	}
+	form.setOptions(*m.options)
+	return m, input.Blink
+}
+
+// setOptions fills in the pickers. A new issue starts from the template of
+// the repository if there is only one, or else asks to choose one.
+func (f *issueForm) setOptions(options optionsLoadedMsg) {
+	f.options = &options
+	if f.issue != nil || len(options.templates) == 0 {
+		return
+	}
+	if len(options.templates) == 1 {
+		f.applyTemplate(1)
+	} else if f.title.Value() == "" && f.body == "" {
+		f.setFocus(fieldTemplate)
+	}
+}
+
+// applyTemplate switches to another template. What was written from the
+// previous template is replaced, while changes to it are kept.
+func (f *issueForm) applyTemplate(template int) {
+	previous := f.currentTemplate()
+	f.template = template
+	next := f.currentTemplate()
+	if f.title.Value() == previous.Title {
+		f.title.SetValue(next.Title)
+		f.title.CursorEnd()
+	}
+	if f.body == previous.Body {
+		f.body = next.Body
+	}
+	if sameValues(f.labels, previous.Labels) {
+		f.labels = next.Labels
+	}
+	if sameValues(f.assignees, previous.Assignees) {
+		f.assignees = next.Assignees
+	}
+}
+
+func (f issueForm) currentTemplate() issueTemplate {
+	if f.options == nil || f.template == 0 || f.template > len(f.options.templates) {
+		return issueTemplate{}
+	}
+	return f.options.templates[f.template-1]
+}
+
+func (f issueForm) hasTemplates() bool {
+	return f.issue == nil && f.options != nil && len(f.options.templates) > 0
+}
+
+func (f *issueForm) setFocus(focus int) {
+	f.focus = focus
+	if focus == fieldTitle {
+		f.title.Focus()
+	} else {
+		f.title.Blur()
+	}
+}
+
+// moveFocus goes to the next or previous field, skipping the template when
+// there is none to choose.
+func (f *issueForm) moveFocus(delta int) {
+	focus := (f.focus + delta + fieldCount) % fieldCount
+	if focus == fieldTemplate && !f.hasTemplates() {
+		focus = (focus + delta + fieldCount) % fieldCount
+	}
+	f.setFocus(focus)
+}
+
+func (f *issueForm) setBody(msg bodyEditedMsg) {
+	if msg.err != nil {
+		f.err = "Could not edit the description: " + msg.err.Error()
+		return
+	}
+	f.err = ""
+	f.body = msg.text
+}
+
+func updateForm(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	form := m.form
+	if form.saving {
+		return m, nil
+	}
+	if form.picker != nil {
+		done, cmd := form.picker.update(msg)
+		if done {
+			form.choose(*form.picker)
+			form.picker = nil
+		}
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.form = nil
+		return m, nil
+	case "tab", "down":
+		form.moveFocus(1)
+		return m, input.Blink
+	case "shift+tab", "up":
+		form.moveFocus(-1)
+		return m, input.Blink
+	case "ctrl+s":
+		return m.submitForm()
+	}
+
+	switch form.focus {
+	case fieldTemplate:
+		count := len(form.options.templates) + 1
+		switch msg.String() {
+		case "left", "h":
+			form.applyTemplate((form.template + count - 1) % count)
+		case "right", "l", " ":
+			form.applyTemplate((form.template + 1) % count)
+		case "enter":
+			form.moveFocus(1)
+		}
+	case fieldTitle:
+		if msg.Type == tea.KeyEnter {
+			form.moveFocus(1)
+			return m, nil
+		}
+		var cmd tea.Cmd
+		form.title, cmd = form.title.Update(msg)
+		return m, cmd
+	case fieldBody:
+		if msg.String() == "enter" || msg.String() == "e" {
+			return m, common.Edit("issue.md", form.body, func(text string, err error) tea.Msg {
+				return bodyEditedMsg{text: text, err: err}
+			})
+		}
+	case fieldLabels, fieldAssignees, fieldMilestone:
+		if msg.String() != "enter" && msg.String() != " " {
+			return m, nil
+		}
+		if form.options == nil {
+			form.err = "Still loading the labels, assignees and milestones."
+			return m, nil
+		}
+		form.err = ""
+		p := form.newPicker()
+		form.picker = &p
+		return m, input.Blink
+	}
+	return m, nil
+}
+
+// newPicker opens the picker of the focused field.
+func (f issueForm) newPicker() picker {
+	switch f.focus {
+	case fieldLabels:
+		var options []option
+		for _, label := range f.options.labels {
+			options = append(options, option{value: label.GetName(), label: label.GetName(), description: label.GetDescription()})
+		}
+		return newPicker("Labels", sortedOptions(options), f.labels, true)
+	case fieldAssignees:
+		var options []option
+		for _, user := range f.options.assignees {
+			options = append(options, option{value: user.GetLogin(), label: user.GetLogin()})
+		}
+		return newPicker("Assignees", sortedOptions(options), f.assignees, true)
+	default:
+		var options []option
+		for _, milestone := range f.options.milestones {
+			description := ""
+			if milestone.DueOn != nil {
+				description = "due " + milestone.GetDueOn().Format("Jan 2, 2006")
+			}
+			options = append(options, option{value: strconv.Itoa(milestone.GetNumber()), label: milestone.GetTitle(), description: description})
+		}
+		var selected []string
+		if f.milestone != 0 {
+			selected = append(selected, strconv.Itoa(f.milestone))
+		}
+		return newPicker("Milestone", options, selected, false)
+	}
+}
+
+// choose takes the choice of a closed picker.
+func (f *issueForm) choose(p picker) {
+	switch f.focus {
+	case fieldLabels:
+		f.labels = p.values()
+	case fieldAssignees:
+		f.assignees = p.values()
+	case fieldMilestone:
+		f.milestone = 0
+		if values := p.values(); len(values) > 0 {
+			f.milestone, _ = strconv.Atoi(values[0])
+		}
+	}
+}
+
+func (f issueForm) View() string {
+	if f.picker != nil {
+		return f.picker.View()
+	}
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	field := func(focus int, label string, value string) string {
+		return common.FormField(f.focus == focus, label, value)
+	}
+	none := gray.Render("none")
+	list := func(values []string) string {
+		if len(values) == 0 {
+			return none
+		}
+		return strings.Join(values, ", ")
+	}
+
+	heading := "New issue"
+	if f.issue != nil {
+		heading = fmt.Sprintf("Edit #%d", f.issue.GetNumber())
+	}
+	lines := []string{heading, ""}
+
+	if f.hasTemplates() {
+		names := []string{"Blank issue"}
+		for _, template := range f.options.templates {
+			names = append(names, template.Name)
+		}
+		var choices []string
+		for i, name := range names {
+			if i == f.template {
+				choices = append(choices, common.PaneSelectedItemStyle().Render(" "+name+" "))
+			} else {
+				choices = append(choices, " "+name+" ")
+			}
+		}
+		lines = append(lines, field(fieldTemplate, "Template", strings.Join(choices, "")))
+		if about := f.currentTemplate().About; about != "" {
+			lines = append(lines, common.FormField(false, "", gray.Render(about)))
+		}
+	}
+
+	lines = append(lines, field(fieldTitle, "Title *", f.title.View()))
+	if strings.TrimSpace(f.body) == "" {
+		lines = append(lines, field(fieldBody, "Description", gray.Render("empty; press enter to write it in $EDITOR")))
+	} else {
+		body := strings.Split(strings.TrimRight(f.body, "\n"), "\n")
+		lines = append(lines, field(fieldBody, "Description", gray.Render(common.Plural(len(body), "line")+"; press enter to edit in $EDITOR")))
+		for i, line := range body {
+			if i == 6 {
+				lines = append(lines, common.FormField(false, "", gray.Render("…")))
+				break
+			}
+			lines = append(lines, common.FormField(false, "", truncate.StringWithTail(line, 80, "…")))
+		}
+	}
+	lines = append(lines,
+		field(fieldLabels, "Labels", list(f.labels)),
+		field(fieldAssignees, "Assignees", list(f.assignees)),
+		field(fieldMilestone, "Milestone", f.milestoneTitle(none)),
+		"",
+	)
+	return common.FormView(lines, f.err, "tab/↑/↓ move · enter edit or choose · ←/→ template · ctrl+s save · esc cancel")
+}
+
+func (f issueForm) milestoneTitle(none string) string {
+	if f.milestone == 0 {
+		return none
+	}
+	if f.options != nil {
+		for _, milestone := range f.options.milestones {
+			if milestone.GetNumber() == f.milestone {
+				return milestone.GetTitle()
+			}
+		}
+	}
+	if f.issue != nil && f.issue.GetMilestone().GetNumber() == f.milestone {
+		return f.issue.GetMilestone().GetTitle()
+	}
+	return fmt.Sprintf("#%d", f.milestone)
+}
+
+func (m Model) submitForm() (Model, tea.Cmd) {
+	form := m.form
+	title := strings.TrimSpace(form.title.Value())
+	if title == "" {
+		form.err = "a title is required"
+		return m, nil
+	}
+	labels := append([]string{}, form.labels...)
+	assignees := append([]string{}, form.assignees...)
+	request := &github.IssueRequest{
+		Title:     github.String(title),
+		Body:      github.String(form.body),
+		Labels:    &labels,
+		Assignees: &assignees,
+	}
+	if form.milestone != 0 {
+		request.Milestone = github.Int(form.milestone)
+	}
+	form.saving = true
+	form.err = ""
+	if form.issue == nil {
+		m.statusMsg = "Creating the issue..."
+	} else {
+		m.statusMsg = fmt.Sprintf("Saving #%d...", form.issue.GetNumber())
+	}
+	return m, m.saveIssue(form.issue, request)
+}
+
+// saveIssue creates an issue, or updates issue when it is not nil.
+func (m Model) saveIssue(issue *github.Issue, request *github.IssueRequest) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		owner, name := m.owner(), m.repository.GetName()
+		if issue == nil {
+			created, _, err := m.gh.Issues.Create(ctx, owner, name, request)
+			return issueSavedMsg{issue: created, created: true, err: err}
+		}
+		saved, _, err := m.gh.Issues.Edit(ctx, owner, name, issue.GetNumber(), request)
+		if err != nil {
+			return issueSavedMsg{err: err}
+		}
+		// A request can't clear the milestone, as an unset one is left out.
+		if request.Milestone == nil && saved.Milestone != nil {
+			endpoint := fmt.Sprintf("repos/%s/%s/issues/%d", url.PathEscape(owner), url.PathEscape(name), issue.GetNumber())
+			req, err := m.gh.NewRequest(http.MethodPatch, endpoint, map[string]interface{}{"milestone": nil})
+			if err != nil {
+				return issueSavedMsg{err: err}
+			}
+			saved = new(github.Issue)
+			if _, err := m.gh.Do(ctx, req, saved); err != nil {
+				return issueSavedMsg{err: err}
+			}
+		}
+		return issueSavedMsg{issue: saved}
+	}
+}
+
+// updateIssueSaved shows the saved issue, or the error in the form so
+// nothing written is lost.
+func updateIssueSaved(m Model, msg issueSavedMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.statusMsg = ""
+		if m.form != nil {
+			m.form.saving = false
+			m.form.err = "Could not save the issue: " + msg.err.Error()
+		}
+		return m, nil
+	}
+	m.form = nil
+	if msg.created {
+		m.statusMsg = fmt.Sprintf("Created #%d.", msg.issue.GetNumber())
+		if states[m.state] != "closed" {
+			m.issues = append([]*github.Issue{msg.issue}, m.issues...)
+			m.index = 0
+			m.move(0)
+		}
+		m.issue = newIssueView(msg.issue, m.width, m.height)
+		return m, nil
+	}
+	m.statusMsg = fmt.Sprintf("Saved #%d.", msg.issue.GetNumber())
+	m.setIssue(msg.issue)
+	return m, nil
+}
+
+// sameValues reports whether a and b hold the same values in any order.
+func sameValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[string]int{}
+	for _, value := range a {
+		seen[value]++
+	}
+	for _, value := range b {
+		if seen[value] == 0 {
+			return false
+		}
+		seen[value]--
+	}
+	return true
+}
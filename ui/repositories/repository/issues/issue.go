@@ -0,0 +1,288 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/browser"
+	"ghtui/ghtui/ui/common"
+)
+
+// issueLoadedMsg carries an issue that was fetched again or changed.
+type issueLoadedMsg *github.Issue
+
+// issueView shows an issue with its rendered description and comments. One
+// of them is selected to be edited or reacted to.
+type issueView struct {
+	issue    *github.Issue
+	comments []*github.IssueComment
+	// loaded is set once the comments were fetched.
+	loaded   bool
+	viewport viewport.Model
+	// selected is 0 for the issue itself and i for comments[i-1].
+	selected int
+	// offsets are the lines the issue and each comment start at.
+	offsets []int
+	picker  *picker
+	// choose runs the choice of the picker, if it was not cancelled.
+	choose  func(values []string) tea.Cmd
+	confirm *confirmation
+}
+
+// confirmation asks before running an action on an issue.
+type confirmation struct {
+	prompt string
+	action tea.Cmd
+}
+
+func newIssueView(issue *github.Issue, width int, height int) *issueView {
+	v := &issueView{issue: issue}
+	v.resize(width, height)
+	return v
+}
+
+func (v *issueView) resize(width int, height int) {
+	v.viewport.Width = width
+	v.viewport.Height = height - 3
+	v.render()
+}
+
+func (v *issueView) setIssue(issue *github.Issue) {
+	v.issue = issue
+	v.render()
+}
+
+func (v *issueView) setComments(comments []*github.IssueComment) {
+	v.comments = comments
+	v.loaded = true
+	if v.selected > len(comments) {
+		v.selected = len(comments)
+	}
+	v.render()
+}
+
+func (v *issueView) render() {
+	v.viewport.SetContent(v.content())
+}
+
+// comment returns the selected comment, if one is selected rather than the
+// issue.
+func (v issueView) comment() (*github.IssueComment, bool) {
+	if v.selected == 0 || v.selected > len(v.comments) {
+		return nil, false
+	}
+	return v.comments[v.selected-1], true
+}
+
+// selectNext selects the next or previous comment and scrolls to it.
+func (v *issueView) selectNext(delta int) {
+	v.selected += delta
+	if v.selected > len(v.comments) {
+		v.selected = len(v.comments)
+	}
+	if v.selected < 0 {
+		v.selected = 0
+	}
+	v.render()
+	v.viewport.YOffset = v.offsets[v.selected]
+}
+
+// content is the header of the issue followed by its description and the
+// comments, with a bar next to the selected one.
+func (v *issueView) content() string {
+	width := v.viewport.Width - 2
+	issue := v.issue
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	facts := []string{issue.GetState(), "opened by " + issue.GetUser().GetLogin() + " " + common.RelativeTime(issue.GetCreatedAt())}
+	if n := issue.GetComments(); n > 0 {
+		facts = append(facts, common.Plural(n, "comment"))
+	}
+	lines := []string{
+		lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("#%d %s", issue.GetNumber(), issue.GetTitle())),
+		gray.Render(strings.Join(facts, " · ")),
+	}
+
+	var labels, assignees []string
+	for _, label := range issue.Labels {
+		labels = append(labels, label.GetName())
+	}
+	for _, assignee := range issue.Assignees {
+		assignees = append(assignees, assignee.GetLogin())
+	}
+	if len(labels) > 0 {
+		lines = append(lines, "Labels: "+strings.Join(labels, ", "))
+	}
+	if len(assignees) > 0 {
+		lines = append(lines, "Assignees: "+strings.Join(assignees, ", "))
+	}
+	if issue.Milestone != nil {
+		lines = append(lines, "Milestone: "+issue.GetMilestone().GetTitle())
+	}
+	lines = append(lines, "")
+
+	if strings.TrimSpace(issue.GetBody()) == "" {
+		lines = append(lines, gray.Render("No description provided."))
+	} else {
+		lines = append(lines, common.RenderMarkdown(issue.GetBody(), width))
+	}
+	if reactions := reactionsLine(issue.Reactions); reactions != "" {
+		lines = append(lines, reactions)
+	}
+	sections := []string{strings.Join(lines, "\n")}
+
+	for _, comment := range v.comments {
+		header := lipgloss.NewStyle().Bold(true).Render(comment.GetUser().GetLogin()) +
+			gray.Render(" commented "+common.RelativeTime(comment.GetCreatedAt()))
+		if !comment.GetUpdatedAt().Equal(comment.GetCreatedAt()) {
+			header += gray.Render(" · edited")
+		}
+		lines := []string{header, common.RenderMarkdown(comment.GetBody(), width)}
+		if reactions := reactionsLine(comment.Reactions); reactions != "" {
+			lines = append(lines, reactions)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	if !v.loaded && issue.GetComments() > 0 {
+		sections = append(sections, gray.Render("Loading comments..."))
+	}
+
+	bar := common.MatchStyle().Render("▌") + " "
+	var out []string
+	v.offsets = v.offsets[:0]
+	for i, section := range sections {
+		v.offsets = append(v.offsets, len(out))
+		gutter := "  "
+		if i == v.selected {
+			gutter = bar
+		}
+		for _, line := range strings.Split(strings.TrimRight(section, "\n"), "\n") {
+			out = append(out, gutter+line)
+		}
+		out = append(out, "")
+	}
+	return strings.Join(out, "\n")
+}
+
+func (v issueView) View(title string, status string, help string) string {
+	footer := lipgloss.NewStyle().Foreground(common.GrayColor()).Render(help)
+	switch {
+	case v.picker != nil:
+		return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, title, "", v.picker.View()))
+	case v.confirm != nil:
+		footer = v.confirm.prompt
+	case status != "":
+		footer = status
+	}
+	return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, title, v.viewport.View(), footer))
+}
+
+func (m Model) openIssue(issue *github.Issue) (Model, tea.Cmd) {
+	m.issue = newIssueView(issue, m.width, m.height)
+	m.statusMsg = ""
+	return m, m.reloadIssue(issue.GetNumber())
+}
+
+func updateIssueView(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	view := m.issue
+	issue := view.issue
+	if view.picker != nil {
+		done, cmd := view.picker.update(msg)
+		if done {
+			values := view.picker.values()
+			view.picker = nil
+			if msg.String() == "enter" && len(values) > 0 {
+				return m, view.choose(values)
+			}
+		}
+		return m, cmd
+	}
+	if view.confirm != nil {
+		confirm := view.confirm
+		view.confirm = nil
+		if msg.String() == "y" {
+			return m, confirm.action
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.issue = nil
+		m.statusMsg = ""
+	case "tab":
+		view.selectNext(1)
+	case "shift+tab":
+		view.selectNext(-1)
+	case "c":
+		return m, m.writeComment(nil)
+	case "e":
+		if comment, ok := view.comment(); ok {
+			if !m.own(comment) {
+				m.statusMsg = "Only your own comments can be edited."
+				return m, nil
+			}
+			return m, m.writeComment(comment)
+		}
+		return m.openForm(issue)
+	case "d":
+		if comment, ok := view.comment(); ok {
+			if !m.own(comment) {
+				m.statusMsg = "Only your own comments can be deleted."
+				return m, nil
+			}
+			view.confirm = &confirmation{
+				prompt: "Delete your comment? (y/n)",
+				action: m.deleteComment(issue.GetNumber(), comment),
+			}
+		}
+	case "+":
+		m.openReactions()
+		return m, nil
+	case "m":
+		if m.pulls {
+			return m.openMerge()
+		}
+	case "C":
+		if m.pulls {
+			m.statusMsg = fmt.Sprintf("Checking out #%d...", issue.GetNumber())
+			return m, m.checkoutPull(issue.GetNumber())
+		}
+	case "x":
+		return m.toggleState()
+	case "r":
+		m.statusMsg = fmt.Sprintf("Loading #%d...", issue.GetNumber())
+		return m, m.reloadIssue(issue.GetNumber())
+	case "o":
+		if comment, ok := view.comment(); ok {
+			return m, browser.OpenCmd(m.config, comment.GetHTMLURL())
+		}
+		return m, browser.OpenCmd(m.config, issue.GetHTMLURL())
+	case "y":
+		if comment, ok := view.comment(); ok {
+			return m, browser.CopyCmd(comment.GetHTMLURL())
+		}
+		return m, browser.CopyCmd(issue.GetHTMLURL())
+	default:
+		var cmd tea.Cmd
+		view.viewport, cmd = view.viewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// loadIssue fetches an issue again, as the list may be outdated.
+func (m Model) loadIssue(number int) tea.Cmd {
+	return func() tea.Msg {
+		issue, _, err := m.gh.Issues.Get(context.Background(), m.owner(), m.repository.GetName(), number)
+		if err != nil {
+			return errorMsg(err)
+		}
+		return issueLoadedMsg(issue)
+	}
+}
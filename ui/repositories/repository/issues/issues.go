@@ -0,0 +1,588 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/browser"
+	"ghtui/ghtui/config"
+	"ghtui/ghtui/ui/common"
+)
+
+const (
+	issuesPerPage = 50
+	// issuePages caps the pages of issues looked through to fill the list.
+	issuePages = 10
+)
+
+// states are the issue states the list can be filtered by, in the order
+// they are cycled through.
+var states = []string{"open", "closed", "all"}
+
+type issuesLoadedMsg struct {
+	state  string
+	issues []*github.Issue
+}
+
+// optionsLoadedMsg carries what the issue form offers to choose from.
+type optionsLoadedMsg struct {
+	labels     []*github.Label
+	assignees  []*github.User
+	milestones []*github.Milestone
+	templates  []issueTemplate
+	// skipped tells why templates that couldn't be read were left out.
+	skipped []error
+	err     error
+}
+type errorMsg error
+type statusMsg string
+
+type status int
+
+const (
+	statusLoading status = iota
+	statusReady
+)
+
+// Model lists the issues or the pull requests of a repository, shows them
+// one at a time with their comments and creates or edits them.
+type Model struct {
+	Done bool
+
+	// pulls is set when the model lists pull requests rather than issues.
+	pulls bool
+
+	user       *github.User
+	repository *github.Repository
+	gh         *github.Client
+	config     *config.Config
+	spinner    spinner.Model
+	status     status
+	statusMsg  string
+	state      int
+	issues     []*github.Issue
+	index      int
+	offset     int
+	width      int
+	height     int
+	// issue is the issue being read, if any.
+	issue    *issueView
+	form     *issueForm
+	pullForm *pullForm
+	merge    *mergeForm
+
+	// The labels, assignees, milestones and templates of the repository,
+	// loaded the first time the form is opened.
+	options *optionsLoadedMsg
+}
+
+func NewModel(user *github.User, repository *github.Repository, gh *github.Client, cfg *config.Config) Model {
+	width, height := common.ScreenSize()
+	top, right, bottom, left := common.AppStyle().GetPadding()
+	return Model{
+		user:       user,
+		repository: repository,
+		gh:         gh,
+		config:     cfg,
+		spinner:    common.NewSpinnerModel(),
+		status:     statusLoading,
+		width:      width - left - right,
+		height:     height - top - bottom,
+	}
+}
+
+// NewPullsModel lists the pull requests of a repository, which GitHub
+// comments on, reacts to and closes the same way as issues.
+func NewPullsModel(user *github.User, repository *github.Repository, gh *github.Client, cfg *config.Config) Model {
+	m := NewModel(user, repository, gh, cfg)
+	m.pulls = true
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.loadIssues(states[m.state]), spinner.Tick)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.form != nil {
+			return updateForm(m, msg)
+		}
+		if m.pullForm != nil {
+			return updatePullForm(m, msg)
+		}
+		if m.merge != nil {
+			return updateMergeForm(m, msg)
+		}
+		if m.issue != nil {
+			return updateIssueView(m, msg)
+		}
+		switch msg.String() {
+		case "esc":
+			m.Done = true
+		case "up", "k":
+			m.move(-1)
+		case "down", "j":
+			m.move(1)
+		case "pgup":
+			m.move(-m.visibleRows())
+		case "pgdown":
+			m.move(m.visibleRows())
+		case "s":
+			m.state = (m.state + 1) % len(states)
+			return m.reload()
+		case "r":
+			return m.reload()
+		case "enter":
+			if issue, ok := m.selected(); ok {
+				return m.openIssue(issue)
+			}
+		case "n":
+			if m.pulls {
+				return m.openPullForm()
+			}
+			return m.openForm(nil)
+		case "e":
+			if issue, ok := m.selected(); ok {
+				return m.openForm(issue)
+			}
+		case "o":
+			if issue, ok := m.selected(); ok {
+				return m, browser.OpenCmd(m.config, issue.GetHTMLURL())
+			}
+		case "y":
+			if issue, ok := m.selected(); ok {
+				return m, browser.CopyCmd(issue.GetHTMLURL())
+			}
+		}
+	case tea.WindowSizeMsg:
+		top, right, bottom, left := common.AppStyle().GetPadding()
+		m.width = msg.Width - left - right
+		m.height = msg.Height - top - bottom
+		if m.issue != nil {
+			m.issue.resize(m.width, m.height)
+		}
+		if m.pullForm != nil {
+			m.pullForm.resize(m.width, m.height)
+		}
+	case spinner.TickMsg:
+		m.spinner, cmd = m.spinner.Update(msg)
+	case issuesLoadedMsg:
+		if msg.state != states[m.state] {
+			return m, nil
+		}
+		m.status = statusReady
+		m.statusMsg = ""
+		m.issues = msg.issues
+		m.move(0)
+	case issueLoadedMsg:
+		if m.statusMsg == fmt.Sprintf("Loading #%d...", (*github.Issue)(msg).GetNumber()) {
+			m.statusMsg = ""
+		}
+		m.setIssue(msg)
+	case commentsLoadedMsg:
+		if m.issue != nil && m.issue.issue.GetNumber() == msg.number {
+			m.issue.setComments(msg.comments)
+		}
+	case commentWrittenMsg:
+		return updateCommentWritten(m, msg)
+	case changedMsg:
+		m.statusMsg = msg.status
+		return m, m.reloadIssue(msg.number)
+	case optionsLoadedMsg:
+		if msg.err != nil {
+			m.statusMsg = "Could not load the labels, assignees and milestones: " + msg.err.Error()
+			return m, nil
+		}
+		m.options = &msg
+		if m.form != nil {
+			m.form.setOptions(msg)
+		}
+		if len(msg.skipped) > 0 {
+			reasons := make([]string, len(msg.skipped))
+			for i, err := range msg.skipped {
+				reasons[i] = err.Error()
+			}
+			m.statusMsg = "Skipped " + common.Plural(len(msg.skipped), "issue template") + ": " + strings.Join(reasons, "; ")
+		}
+	case pullOptionsLoadedMsg:
+		if m.pullForm == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMsg = "Could not load the branches, reviewers and labels: " + msg.err.Error()
+			return m, nil
+		}
+		m.statusMsg = ""
+		m.pullForm.setOptions(msg)
+	case comparedMsg:
+		if m.pullForm != nil {
+			m.pullForm.setComparison(msg)
+		}
+	case pullCreatedMsg:
+		return updatePullCreated(m, msg)
+	case mergeInfoLoadedMsg:
+		if m.issue == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMsg = "Could not check whether it can be merged: " + msg.err.Error()
+			return m, nil
+		}
+		m.statusMsg = ""
+		m.merge = newMergeForm(msg)
+	case mergedMsg:
+		return updateMerged(m, msg)
+	case bodyEditedMsg:
+		if m.form != nil {
+			m.form.setBody(msg)
+		}
+		if m.pullForm != nil {
+			m.pullForm.setBody(msg)
+		}
+		if m.merge != nil {
+			m.merge.setMessage(msg)
+		}
+	case issueSavedMsg:
+		return updateIssueSaved(m, msg)
+	case statusMsg:
+		m.statusMsg = string(msg)
+	case browser.OpenedMsg:
+		m.statusMsg = msg.Status()
+	case browser.CopiedMsg:
+		m.statusMsg = msg.Status()
+	case errorMsg:
+		m.status = statusReady
+		m.statusMsg = msg.Error()
+	}
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if m.form != nil {
+		return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, m.title(), "", m.form.View(), m.statusMsg))
+	}
+	if m.pullForm != nil {
+		return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, m.title(), "", m.pullForm.View(), m.statusMsg))
+	}
+	if m.merge != nil {
+		return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, m.title(), "", m.merge.View(), m.statusMsg))
+	}
+	if m.issue != nil {
+		help := "tab next comment · c comment · e edit · d delete · + react · x close/reopen · r refresh · o open in browser · y copy link · esc back"
+		if m.pulls {
+			help = "tab next comment · c comment · e edit · d delete · + react · m merge · C check out · x close/reopen · r refresh · o open in browser · y copy link · esc back"
+		}
+		return m.issue.View(m.title(), m.statusMsg, help)
+	}
+	if m.status == statusLoading {
+		return common.AppStyle().Render(m.title() + "\n\n" + m.spinner.View() + " Loading " + m.noun() + "s...")
+	}
+
+	var rows []string
+	end := m.offset + m.visibleRows()
+	if end > len(m.issues) {
+		end = len(m.issues)
+	}
+	for i := m.offset; i < end; i++ {
+		row := truncate.StringWithTail(issueRow(m.issues[i]), uint(m.width), "…")
+		if i == m.index {
+			row = common.PaneSelectedItemStyle().Render(row)
+		}
+		rows = append(rows, row)
+	}
+	if len(m.issues) == 0 {
+		rows = append(rows, "No "+states[m.state]+" "+m.noun()+"s.")
+	}
+
+	footer := lipgloss.NewStyle().Foreground(common.GrayColor()).
+		Render("enter read · n new · e edit · s open/closed/all · r refresh · o open in browser · y copy link · esc back")
+	if m.statusMsg != "" {
+		footer = m.statusMsg
+	}
+	filters := lipgloss.NewStyle().Foreground(common.GrayColor()).
+		Render(fmt.Sprintf("state: %s  (%d shown)", states[m.state], len(m.issues)))
+	return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left,
+		m.title(),
+		filters,
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		footer,
+	))
+}
+
+func (m Model) title() string {
+	if m.pulls {
+		return common.ListTitleStyle().Render(m.repository.GetName() + " · Pull requests")
+	}
+	return common.ListTitleStyle().Render(m.repository.GetName() + " · Issues")
+}
+
+func (m Model) noun() string {
+	if m.pulls {
+		return "pull request"
+	}
+	return "issue"
+}
+
+// visibleRows is how many issues fit between the header and the footer.
+func (m Model) visibleRows() int {
+	if rows := m.height - 6; rows > 1 {
+		return rows
+	}
+	return 1
+}
+
+// move shifts the selection and scrolls it into view.
+func (m *Model) move(delta int) {
+	m.index += delta
+	if m.index >= len(m.issues) {
+		m.index = len(m.issues) - 1
+	}
+	if m.index < 0 {
+		m.index = 0
+	}
+	if m.index < m.offset {
+		m.offset = m.index
+	} else if m.index >= m.offset+m.visibleRows() {
+		m.offset = m.index - m.visibleRows() + 1
+	}
+}
+
+func (m Model) selected() (*github.Issue, bool) {
+	if m.index < 0 || m.index >= len(m.issues) {
+		return nil, false
+	}
+	return m.issues[m.index], true
+}
+
+func (m Model) reload() (Model, tea.Cmd) {
+	m.statusMsg = "Loading " + m.noun() + "s..."
+	return m, m.loadIssues(states[m.state])
+}
+
+// setIssue replaces an issue that changed in the list and in the issue view.
+func (m *Model) setIssue(issue *github.Issue) {
+	for i, other := range m.issues {
+		if other.GetNumber() == issue.GetNumber() {
+			m.issues[i] = issue
+		}
+	}
+	if m.issue != nil && m.issue.issue.GetNumber() == issue.GetNumber() {
+		m.issue.setIssue(issue)
+	}
+}
+
+func (m Model) owner() string {
+	return m.repository.GetOwner().GetLogin()
+}
+
+// loadIssues fetches the latest issues or pull requests in state.
+func (m Model) loadIssues(state string) tea.Cmd {
+	return func() tea.Msg {
+		var issues []*github.Issue
+		var err error
+		if m.pulls {
+			issues, err = m.listPulls(state)
+		} else {
+			issues, err = m.listIssues(state)
+		}
+		if err != nil {
+			return errorMsg(err)
+		}
+		return issuesLoadedMsg{state: state, issues: issues}
+	}
+}
+
+// listIssues fetches the latest issuesPerPage issues in state. The API lists
+// pull requests as issues too, so it pages on until enough issues are left
+// once they are dropped, looking at no more than issuePages pages.
+func (m Model) listIssues(state string) ([]*github.Issue, error) {
+	var issues []*github.Issue
+	opts := &github.IssueListByRepoOptions{State: state, ListOptions: github.ListOptions{PerPage: 100}}
+	for i := 0; i < issuePages; i++ {
+		page, resp, err := m.gh.Issues.ListByRepo(context.Background(), m.owner(), m.repository.GetName(), opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range page {
+			if !issue.IsPullRequest() {
+				issues = append(issues, issue)
+			}
+		}
+		if len(issues) >= issuesPerPage {
+			return issues[:issuesPerPage], nil
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return issues, nil
+}
+
+// listPulls fetches the latest issuesPerPage pull requests in state, as the
+// issues they are to the rest of the screen.
+func (m Model) listPulls(state string) ([]*github.Issue, error) {
+	pulls, _, err := m.gh.PullRequests.List(context.Background(), m.owner(), m.repository.GetName(), &github.PullRequestListOptions{
+		State:       state,
+		ListOptions: github.ListOptions{PerPage: issuesPerPage},
+	})
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]*github.Issue, len(pulls))
+	for i, pull := range pulls {
+		issues[i] = pullIssue(pull)
+	}
+	return issues, nil
+}
+
+// pullIssue describes a listed pull request as an issue. The number of its
+// comments isn't listed; it is filled in once the pull request is opened.
+func pullIssue(pull *github.PullRequest) *github.Issue {
+	return &github.Issue{
+		ID:        pull.ID,
+		Number:    pull.Number,
+		State:     pull.State,
+		Locked:    pull.Locked,
+		Title:     pull.Title,
+		Body:      pull.Body,
+		User:      pull.User,
+		Labels:    pull.Labels,
+		Assignee:  pull.Assignee,
+		Assignees: pull.Assignees,
+		Milestone: pull.Milestone,
+		ClosedAt:  pull.ClosedAt,
+		CreatedAt: pull.CreatedAt,
+		UpdatedAt: pull.UpdatedAt,
+		URL:       pull.IssueURL,
+		HTMLURL:   pull.HTMLURL,
+		PullRequestLinks: &github.PullRequestLinks{
+			URL:      pull.URL,
+			HTMLURL:  pull.HTMLURL,
+			DiffURL:  pull.DiffURL,
+			PatchURL: pull.PatchURL,
+		},
+	}
+}
+
+// loadOptions fetches the labels, assignees, milestones and issue templates
+// of the repository.
+func (m Model) loadOptions() tea.Msg {
+	var msg optionsLoadedMsg
+	if msg.labels, msg.err = m.listLabels(); msg.err != nil {
+		return msg
+	}
+	if msg.assignees, msg.err = m.listAssignees(); msg.err != nil {
+		return msg
+	}
+	if msg.milestones, msg.err = m.listMilestones(); msg.err != nil {
+		return msg
+	}
+	msg.templates, msg.skipped, msg.err = m.loadTemplates()
+	return msg
+}
+
+// listBranches fetches every branch of the repository.
+func (m Model) listBranches() ([]*github.Branch, error) {
+	var branches []*github.Branch
+	opts := &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := m.gh.Repositories.ListBranches(context.Background(), m.owner(), m.repository.GetName(), opts)
+		if err != nil {
+			return branches, err
+		}
+		branches = append(branches, page...)
+		if resp.NextPage == 0 {
+			return branches, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listLabels fetches every label of the repository.
+func (m Model) listLabels() ([]*github.Label, error) {
+	var labels []*github.Label
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := m.gh.Issues.ListLabels(context.Background(), m.owner(), m.repository.GetName(), opts)
+		if err != nil {
+			return labels, err
+		}
+		labels = append(labels, page...)
+		if resp.NextPage == 0 {
+			return labels, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listAssignees fetches every user issues of the repository can be assigned
+// to.
+func (m Model) listAssignees() ([]*github.User, error) {
+	var users []*github.User
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := m.gh.Issues.ListAssignees(context.Background(), m.owner(), m.repository.GetName(), opts)
+		if err != nil {
+			return users, err
+		}
+		users = append(users, page...)
+		if resp.NextPage == 0 {
+			return users, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listMilestones fetches every open milestone of the repository.
+func (m Model) listMilestones() ([]*github.Milestone, error) {
+	var milestones []*github.Milestone
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := m.gh.Issues.ListMilestones(context.Background(), m.owner(), m.repository.GetName(), opts)
+		if err != nil {
+			return milestones, err
+		}
+		milestones = append(milestones, page...)
+		if resp.NextPage == 0 {
+			return milestones, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func issueRow(issue *github.Issue) string {
+	icon := "○"
+	if issue.GetState() == "closed" {
+		icon = "✓"
+	}
+	var labels []string
+	for _, label := range issue.Labels {
+		labels = append(labels, label.GetName())
+	}
+	comments := ""
+	if n := issue.GetComments(); n > 0 {
+		comments = common.Plural(n, "comment")
+	}
+	return fmt.Sprintf("%s %-7s %-60s %-16s %-12s %-14s %s",
+		icon,
+		fmt.Sprintf("#%d", issue.GetNumber()),
+		truncate.StringWithTail(issue.GetTitle(), 60, "…"),
+		truncate.StringWithTail(issue.GetUser().GetLogin(), 16, "…"),
+		comments,
+		common.RelativeTime(issue.GetUpdatedAt()),
+		strings.Join(labels, ", "),
+	)
+}
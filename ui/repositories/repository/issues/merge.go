@@ -0,0 +1,589 @@
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// mergeMethods are the ways a pull request can be merged, in the order
+// GitHub offers them.
+var mergeMethods = []struct {
+	name  string
+	label string
+}{
+	{"merge", "Create a merge commit"},
+	{"squash", "Squash and merge"},
+	{"rebase", "Rebase and merge"},
+}
+
+// mergeInfoLoadedMsg carries what decides whether and how a pull request
+// can be merged.
+type mergeInfoLoadedMsg struct {
+	pull       *github.PullRequest
+	repository *github.Repository
+	commits    []*github.RepositoryCommit
+	// failing and pending are the names of the checks of the head commit
+	// that failed or haven't finished.
+	failing []string
+	pending []string
+	// required are the checks the protection of the base branch requires.
+	// It is empty when the branch isn't protected.
+	required []string
+	// protectionHidden is set when the user may not read the protection of
+	// the base branch, so any check may be required.
+	protectionHidden bool
+	// changesRequested are the reviewers whose latest review asks for
+	// changes.
+	changesRequested []string
+	err              error
+}
+
+type mergedMsg struct {
+	number int
+	status string
+	err    error
+}
+
+// The fields of the merge form, in the order they are shown.
+const (
+	mergeFieldMethod = iota
+	mergeFieldTitle
+	mergeFieldMessage
+	mergeFieldAuto
+	mergeFieldDelete
+	mergeFieldCount
+)
+
+// mergeForm merges a pull request with one of the methods the repository
+// allows, or enables auto-merge when it waits for checks or reviews.
+type mergeForm struct {
+	info    mergeInfoLoadedMsg
+	methods []int
+	method  int
+	title   input.Model
+	message string
+	edited  bool
+	auto    bool
+	// deleteBranch deletes the head branch after merging, unless the
+	// repository does it by itself.
+	deleteBranch bool
+	// blocked is why the pull request can't be merged now, if it can't.
+	blocked string
+	// waiting is set when merging only waits for checks or reviews, which
+	// auto-merge waits for too.
+	waiting bool
+	focus   int
+	saving  bool
+	err     string
+}
+
+func (m Model) openMerge() (Model, tea.Cmd) {
+	number := m.issue.issue.GetNumber()
+	m.statusMsg = fmt.Sprintf("Checking whether #%d can be merged...", number)
+	return m, m.loadMergeInfo(number)
+}
+
+func newMergeForm(info mergeInfoLoadedMsg) *mergeForm {
+	f := &mergeForm{info: info}
+	repository := info.repository
+	allowed := []bool{repository.GetAllowMergeCommit(), repository.GetAllowSquashMerge(), repository.GetAllowRebaseMerge()}
+	for i := range mergeMethods {
+		if allowed[i] {
+			f.methods = append(f.methods, i)
+		}
+	}
+	f.title = input.NewModel()
+	f.title.Prompt = ""
+	f.title.Placeholder = "Commit title"
+	f.title.CharLimit = 256
+	f.blocked, f.waiting = mergeBlock(info)
+	if len(f.methods) == 0 {
+		f.blocked = "The repository doesn't allow any merge method."
+	}
+	f.auto = f.waiting && f.canAuto()
+	f.deleteBranch = repository.GetDeleteBranchOnMerge()
+	f.setMethod(0)
+	f.setFocus(mergeFieldMethod)
+	return f
+}
+
+// mergeBlock tells why a pull request can't be merged, and whether it only
+// waits for checks or reviews.
+func mergeBlock(info mergeInfoLoadedMsg) (string, bool) {
+	pull := info.pull
+	number := pull.GetNumber()
+	switch {
+	case pull.GetMerged():
+		return fmt.Sprintf("#%d was already merged.", number), false
+	case pull.GetState() == "closed":
+		return fmt.Sprintf("#%d is closed.", number), false
+	case pull.GetDraft():
+		return fmt.Sprintf("#%d is a draft; mark it ready for review first.", number), false
+	case pull.Mergeable != nil && !pull.GetMergeable(), pull.GetMergeableState() == "dirty":
+		return fmt.Sprintf("#%d has conflicts with %s that must be resolved first.", number, pull.GetBase().GetRef()), false
+	case pull.Mergeable == nil || pull.GetMergeableState() == "unknown":
+		return "GitHub is still checking whether it can be merged; press ctrl+r to check again.", false
+	case pull.GetMergeableState() == "behind":
+		return fmt.Sprintf("%s is behind %s and must be brought up to date first.", pull.GetHead().GetRef(), pull.GetBase().GetRef()), false
+	case pull.GetMergeableState() != "blocked":
+		return "", false
+	case info.protectionHidden && len(info.failing) > 0:
+		return fmt.Sprintf("Checks are failing: %s. The protection of %s can't be read, so they may be required.",
+			strings.Join(info.failing, ", "), pull.GetBase().GetRef()), false
+	case len(info.requiredOf(info.failing)) > 0:
+		return "Required checks are failing: " + strings.Join(info.requiredOf(info.failing), ", ") + ".", false
+	case len(info.changesRequested) > 0:
+		return "Changes were requested by " + strings.Join(info.changesRequested, ", ") + ".", false
+	case info.protectionHidden && len(info.pending) > 0:
+		return "Waiting for checks: " + strings.Join(info.pending, ", ") + ".", true
+	case len(info.requiredOf(info.pending)) > 0:
+		return "Waiting for required checks: " + strings.Join(info.requiredOf(info.pending), ", ") + ".", true
+	case info.protectionHidden:
+		return fmt.Sprintf("The protection of %s blocks merging, but it can't be read to tell why.", pull.GetBase().GetRef()), true
+	}
+	return fmt.Sprintf("The protection of %s blocks merging, e.g. until required reviews are in.", pull.GetBase().GetRef()), true
+}
+
+// requiredOf returns the checks among names that the base branch requires.
+func (info mergeInfoLoadedMsg) requiredOf(names []string) []string {
+	var required []string
+	for _, name := range names {
+		for _, context := range info.required {
+			if name == context {
+				required = append(required, name)
+				break
+			}
+		}
+	}
+	return required
+}
+
+// unprotected reports whether err says the branch requires no checks, rather
+// than that its protection may not be read.
+func unprotected(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response.StatusCode != http.StatusNotFound {
+		return false
+	}
+	return errResp.Message == "Branch not protected" || errResp.Message == "Required status checks not enabled"
+}
+
+// optionalChecks returns the failing checks the base branch doesn't require.
+func optionalChecks(info mergeInfoLoadedMsg) []string {
+	if info.protectionHidden {
+		return nil
+	}
+	var optional []string
+	for _, name := range info.failing {
+		if len(info.requiredOf([]string{name})) == 0 {
+			optional = append(optional, name)
+		}
+	}
+	return optional
+}
+
+func (f mergeForm) canAuto() bool {
+	return f.info.repository.GetAllowAutoMerge()
+}
+
+// currentMethod is the name of the chosen merge method.
+func (f mergeForm) currentMethod() string {
+	if len(f.methods) == 0 {
+		return ""
+	}
+	return mergeMethods[f.methods[f.method]].name
+}
+
+// setMethod switches the merge method, and writes the commit message GitHub
+// would unless it was changed.
+func (f *mergeForm) setMethod(method int) {
+	f.method = method
+	if f.edited {
+		return
+	}
+	pull := f.info.pull
+	switch f.currentMethod() {
+	case "merge":
+		f.title.SetValue(fmt.Sprintf("Merge pull request #%d from %s", pull.GetNumber(), pull.GetHead().GetLabel()))
+		f.message = pull.GetTitle()
+	case "squash":
+		f.title.SetValue(fmt.Sprintf("%s (#%d)", pull.GetTitle(), pull.GetNumber()))
+		var lines []string
+		for _, commit := range f.info.commits {
+			lines = append(lines, "* "+strings.TrimSpace(commit.GetCommit().GetMessage()))
+		}
+		f.message = strings.Join(lines, "\n\n")
+	}
+	f.title.CursorEnd()
+}
+
+// hasMessage reports whether the method creates a commit whose message can
+// be written. Rebasing keeps the commits as they are.
+func (f mergeForm) hasMessage() bool {
+	return f.currentMethod() != "rebase"
+}
+
+func (f *mergeForm) setFocus(focus int) {
+	f.focus = focus
+	if focus == mergeFieldTitle {
+		f.title.Focus()
+	} else {
+		f.title.Blur()
+	}
+}
+
+// moveFocus goes to the next or previous field, skipping those that don't
+// apply.
+func (f *mergeForm) moveFocus(delta int) {
+	focus := f.focus
+	for {
+		focus = (focus + delta + mergeFieldCount) % mergeFieldCount
+		switch {
+		case (focus == mergeFieldTitle || focus == mergeFieldMessage) && !f.hasMessage():
+		case focus == mergeFieldAuto && !f.canAuto():
+		default:
+			f.setFocus(focus)
+			return
+		}
+	}
+}
+
+func (f *mergeForm) setMessage(msg bodyEditedMsg) {
+	if msg.err != nil {
+		f.err = "Could not edit the message: " + msg.err.Error()
+		return
+	}
+	f.err = ""
+	f.message = strings.TrimRight(msg.text, "\n")
+	f.edited = true
+}
+
+func updateMergeForm(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	form := m.merge
+	if form.saving {
+		return m, nil
+	}
+	switch msg.String() {
+	case "esc":
+		m.merge = nil
+		m.statusMsg = ""
+		return m, nil
+	case "tab", "down":
+		form.moveFocus(1)
+		return m, input.Blink
+	case "shift+tab", "up":
+		form.moveFocus(-1)
+		return m, input.Blink
+	case "ctrl+r":
+		return m.openMerge()
+	case "ctrl+s":
+		return m.submitMerge()
+	}
+
+	switch form.focus {
+	case mergeFieldMethod:
+		count := len(form.methods)
+		if count == 0 {
+			return m, nil
+		}
+		switch msg.String() {
+		case "left", "h":
+			form.setMethod((form.method + count - 1) % count)
+		case "right", "l", " ":
+			form.setMethod((form.method + 1) % count)
+		case "enter":
+			form.moveFocus(1)
+		}
+	case mergeFieldTitle:
+		if msg.Type == tea.KeyEnter {
+			form.moveFocus(1)
+			return m, nil
+		}
+		title := form.title.Value()
+		var cmd tea.Cmd
+		form.title, cmd = form.title.Update(msg)
+		if form.title.Value() != title {
+			form.edited = true
+		}
+		return m, cmd
+	case mergeFieldMessage:
+		if msg.String() == "enter" || msg.String() == "e" {
+			return m, common.Edit("merge_message.txt", form.message, func(text string, err error) tea.Msg {
+				return bodyEditedMsg{text: text, err: err}
+			})
+		}
+	case mergeFieldAuto, mergeFieldDelete:
+		if msg.String() != "enter" && msg.String() != " " && msg.String() != "x" {
+			return m, nil
+		}
+		if form.focus == mergeFieldAuto {
+			if form.waiting {
+				form.err = "Merging waits for checks or reviews, so only auto-merge is possible."
+				return m, nil
+			}
+			form.auto = !form.auto
+		} else if !form.info.repository.GetDeleteBranchOnMerge() {
+			form.deleteBranch = !form.deleteBranch
+		}
+	}
+	return m, nil
+}
+
+func (f mergeForm) View() string {
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	field := func(focus int, label string, value string) string {
+		return common.FormField(f.focus == focus, label, value)
+	}
+	check := func(focus int, checked bool) string {
+		return common.FormCursor(f.focus == focus) + common.Checkbox(checked) + " "
+	}
+	pull := f.info.pull
+
+	lines := []string{
+		fmt.Sprintf("Merge #%d into %s", pull.GetNumber(), pull.GetBase().GetRef()),
+		gray.Render(fmt.Sprintf("%s · %s · +%d −%d", pull.GetHead().GetLabel(), common.Plural(pull.GetCommits(), "commit"), pull.GetAdditions(), pull.GetDeletions())),
+		"",
+	}
+	var methods []string
+	for i, method := range f.methods {
+		name := " " + mergeMethods[method].label + " "
+		if i == f.method {
+			name = common.PaneSelectedItemStyle().Render(name)
+		}
+		methods = append(methods, name)
+	}
+	lines = append(lines, field(mergeFieldMethod, "Method", strings.Join(methods, "")))
+	if f.hasMessage() {
+		message := gray.Render("empty; press enter to write it in $EDITOR")
+		if strings.TrimSpace(f.message) != "" {
+			first := strings.SplitN(strings.TrimSpace(f.message), "\n", 2)[0]
+			message = truncate.StringWithTail(first, 60, "…") + gray.Render("  "+common.Plural(strings.Count(f.message, "\n")+1, "line"))
+		}
+		lines = append(lines,
+			field(mergeFieldTitle, "Commit title", f.title.View()),
+			field(mergeFieldMessage, "Message", message),
+		)
+	} else {
+		lines = append(lines, common.FormField(false, "", gray.Render("Rebasing keeps the commits and their messages.")))
+	}
+	if f.canAuto() {
+		lines = append(lines, check(mergeFieldAuto, f.auto)+"Enable auto-merge"+gray.Render(" · merge once checks and reviews pass"))
+	}
+	deleteBranch := check(mergeFieldDelete, f.deleteBranch) + "Delete " + pull.GetHead().GetRef() + " afterwards"
+	if f.info.repository.GetDeleteBranchOnMerge() {
+		deleteBranch += gray.Render(" · done by the repository")
+	} else if pull.GetHead().GetRepo().GetID() != pull.GetBase().GetRepo().GetID() {
+		deleteBranch += gray.Render(" · it is in a fork, so it is kept")
+	} else if f.auto {
+		deleteBranch += gray.Render(" · not with auto-merge")
+	}
+	lines = append(lines, deleteBranch, "")
+
+	if f.blocked != "" {
+		lines = append(lines, common.ErrorStyle().Render(f.blocked))
+	} else if optional := optionalChecks(f.info); len(optional) > 0 {
+		lines = append(lines, gray.Render("Checks are failing, but aren't required: "+strings.Join(optional, ", ")+"."))
+	}
+	return common.FormView(lines, f.err, "tab/↑/↓ move · ←/→ method · enter edit or toggle · ctrl+s merge · ctrl+r check again · esc cancel")
+}
+
+func (m Model) submitMerge() (Model, tea.Cmd) {
+	form := m.merge
+	switch {
+	case form.auto && form.waiting:
+	case form.blocked != "":
+		form.err = "can't merge: " + strings.TrimSuffix(form.blocked, ".")
+		return m, nil
+	case form.hasMessage() && strings.TrimSpace(form.title.Value()) == "":
+		form.err = "a commit title is required"
+		return m, nil
+	}
+	form.saving = true
+	form.err = ""
+	number := form.info.pull.GetNumber()
+	if form.auto {
+		m.statusMsg = fmt.Sprintf("Enabling auto-merge for #%d...", number)
+	} else {
+		m.statusMsg = fmt.Sprintf("Merging #%d...", number)
+	}
+	return m, m.mergePull(*form)
+}
+
+// mergePull merges a pull request and deletes its head branch, or enables
+// auto-merge for it.
+func (m Model) mergePull(form mergeForm) tea.Cmd {
+	pull := form.info.pull
+	method := form.currentMethod()
+	title, message := "", ""
+	if form.hasMessage() {
+		title, message = strings.TrimSpace(form.title.Value()), form.message
+	}
+	return func() tea.Msg {
+		ctx := context.Background()
+		owner, name := m.owner(), m.repository.GetName()
+		number := pull.GetNumber()
+		if form.auto {
+			if err := m.enableAutoMerge(ctx, pull.GetNodeID(), method, title, message); err != nil {
+				return mergedMsg{err: err}
+			}
+			return mergedMsg{number: number, status: fmt.Sprintf("#%d will be merged once checks and reviews pass.", number)}
+		}
+
+		result, _, err := m.gh.PullRequests.Merge(ctx, owner, name, number, message, &github.PullRequestOptions{
+			CommitTitle:        title,
+			SHA:                pull.GetHead().GetSHA(),
+			MergeMethod:        method,
+			DontDefaultIfBlank: form.hasMessage(),
+		})
+		if err != nil {
+			return mergedMsg{err: err}
+		}
+		status := fmt.Sprintf("Merged #%d as %s.", number, common.ShortSHA(result.GetSHA()))
+		head := pull.GetHead()
+		if form.deleteBranch && !form.info.repository.GetDeleteBranchOnMerge() && head.GetRepo().GetID() == pull.GetBase().GetRepo().GetID() {
+			if _, err := m.gh.Git.DeleteRef(ctx, owner, name, "heads/"+head.GetRef()); err != nil {
+				status = fmt.Sprintf("Merged #%d, but could not delete %s: %s", number, head.GetRef(), err)
+			} else {
+				status = fmt.Sprintf("Merged #%d and deleted %s.", number, head.GetRef())
+			}
+		}
+		return mergedMsg{number: number, status: status}
+	}
+}
+
+// enableAutoMerge asks GitHub to merge a pull request once it can. The REST
+// API can't, so it is done through GraphQL, whose endpoint is next to the
+// REST one: api.github.com/graphql, or /api/graphql on GitHub Enterprise.
+func (m Model) enableAutoMerge(ctx context.Context, id string, method string, title string, message string) error {
+	const mutation = `mutation($id: ID!, $method: PullRequestMergeMethod!, $headline: String, $body: String) {
+	enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method, commitHeadline: $headline, commitBody: $body}) {
+		clientMutationId
+	}
+}`
+	variables := map[string]interface{}{"id": id, "method": strings.ToUpper(method)}
+	if title != "" {
+		variables["headline"] = title
+		variables["body"] = message
+	}
+	req, err := m.gh.NewRequest(http.MethodPost, "../graphql", map[string]interface{}{
+		"query":     mutation,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := m.gh.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return errors.New(resp.Errors[0].Message)
+	}
+	return nil
+}
+
+func updateMerged(m Model, msg mergedMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.statusMsg = ""
+		if m.merge != nil {
+			m.merge.saving = false
+			m.merge.err = "Could not merge: " + msg.err.Error()
+		}
+		return m, nil
+	}
+	m.merge = nil
+	m.statusMsg = msg.status
+	return m, m.reloadIssue(msg.number)
+}
+
+// loadMergeInfo fetches the pull request with its mergeability, the merge
+// settings of the repository, and the checks and reviews that may block it.
+func (m Model) loadMergeInfo(number int) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		owner, name := m.owner(), m.repository.GetName()
+		var msg mergeInfoLoadedMsg
+		if msg.pull, _, msg.err = m.gh.PullRequests.Get(ctx, owner, name, number); msg.err != nil {
+			return msg
+		}
+		// Repositories in lists leave out their merge settings.
+		if msg.repository, _, msg.err = m.gh.Repositories.Get(ctx, owner, name); msg.err != nil {
+			return msg
+		}
+		if msg.commits, _, msg.err = m.gh.PullRequests.ListCommits(ctx, owner, name, number, &github.ListOptions{PerPage: 100}); msg.err != nil {
+			return msg
+		}
+
+		reviews, _, err := m.gh.PullRequests.ListReviews(ctx, owner, name, number, &github.ListOptions{PerPage: 100})
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		latest := map[string]string{}
+		var reviewers []string
+		for _, review := range reviews {
+			login := review.GetUser().GetLogin()
+			if review.GetState() == "COMMENTED" {
+				continue
+			}
+			if _, ok := latest[login]; !ok {
+				reviewers = append(reviewers, login)
+			}
+			latest[login] = review.GetState()
+		}
+		for _, login := range reviewers {
+			if latest[login] == "CHANGES_REQUESTED" {
+				msg.changesRequested = append(msg.changesRequested, login)
+			}
+		}
+
+		checks, _, err := m.gh.Repositories.GetRequiredStatusChecks(ctx, owner, name, msg.pull.GetBase().GetRef())
+		switch {
+		case err == nil:
+			msg.required = checks.Contexts
+		case !unprotected(err):
+			msg.protectionHidden = true
+		}
+
+		sha := msg.pull.GetHead().GetSHA()
+		if combined, _, err := m.gh.Repositories.GetCombinedStatus(ctx, owner, name, sha, &github.ListOptions{PerPage: 100}); err == nil {
+			for _, status := range combined.Statuses {
+				switch status.GetState() {
+				case "pending":
+					msg.pending = append(msg.pending, status.GetContext())
+				case "failure", "error":
+					msg.failing = append(msg.failing, status.GetContext())
+				}
+			}
+		}
+		if runs, _, err := m.gh.Checks.ListCheckRunsForRef(ctx, owner, name, sha, &github.ListCheckRunsOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+		}); err == nil {
+			for _, run := range runs.CheckRuns {
+				switch {
+				case run.GetStatus() != "completed":
+					msg.pending = append(msg.pending, run.GetName())
+				case run.GetConclusion() == "failure", run.GetConclusion() == "timed_out",
+					run.GetConclusion() == "cancelled", run.GetConclusion() == "action_required":
+					msg.failing = append(msg.failing, run.GetName())
+				}
+			}
+		}
+		return msg
+	}
+}
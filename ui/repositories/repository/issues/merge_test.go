@@ -0,0 +1,27 @@
+package issues
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+)
+
+func TestMergeBlockWithHiddenProtection(t *testing.T) {
+	pull := &github.PullRequest{
+		Number:         github.Int(1),
+		State:          github.String("open"),
+		Mergeable:      github.Bool(true),
+		MergeableState: github.String("blocked"),
+		Base:           &github.PullRequestBranch{Ref: github.String("main")},
+	}
+	info := mergeInfoLoadedMsg{pull: pull, failing: []string{"lint"}, protectionHidden: true}
+
+	blocked, waiting := mergeBlock(info)
+	if !strings.Contains(blocked, "lint") || waiting {
+		t.Errorf("got %q, waiting %t; want the failing check to block", blocked, waiting)
+	}
+	if optional := optionalChecks(info); len(optional) > 0 {
+		t.Errorf("%q are called optional, though the protection can't be read", optional)
+	}
+}
@@ -0,0 +1,164 @@
+package issues
+
+import (
+	"sort"
+	"strings"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"ghtui/ghtui/ui/common"
+)
+
+const pickerRows = 10
+
+// option is a choice of a picker: a label, an assignee or a milestone.
+type option struct {
+	value       string
+	label       string
+	description string
+}
+
+// picker chooses one or several of a list of options, narrowed down by
+// typing.
+type picker struct {
+	title    string
+	options  []option
+	selected map[string]bool
+	multi    bool
+	filter   input.Model
+	index    int
+}
+
+func newPicker(title string, options []option, selected []string, multi bool) picker {
+	p := picker{title: title, options: options, selected: map[string]bool{}, multi: multi}
+	for _, value := range selected {
+		p.selected[value] = true
+	}
+	p.filter = input.NewModel()
+	p.filter.Prompt = "Filter: "
+	p.filter.Focus()
+	return p
+}
+
+// update handles a key and reports whether the picker is done.
+func (p *picker) update(msg tea.KeyMsg) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		return true, nil
+	case "up", "ctrl+p":
+		if p.index > 0 {
+			p.index--
+		}
+		return false, nil
+	case "down", "ctrl+n":
+		if p.index < len(p.visible())-1 {
+			p.index++
+		}
+		return false, nil
+	case " ":
+		if p.multi {
+			p.toggle()
+			return false, nil
+		}
+	case "enter":
+		if !p.multi {
+			p.toggle()
+		}
+		return true, nil
+	}
+	var cmd tea.Cmd
+	p.filter, cmd = p.filter.Update(msg)
+	if p.index >= len(p.visible()) {
+		p.index = 0
+	}
+	return false, cmd
+}
+
+// toggle selects the option under the cursor. A single choice picker drops
+// the previous choice, or clears it when the same option is chosen again.
+func (p *picker) toggle() {
+	visible := p.visible()
+	if p.index >= len(visible) {
+		return
+	}
+	value := visible[p.index].value
+	selected := p.selected[value]
+	if !p.multi {
+		p.selected = map[string]bool{}
+	}
+	p.selected[value] = !selected
+}
+
+// visible returns the options matching the filter.
+func (p picker) visible() []option {
+	query := strings.ToLower(strings.TrimSpace(p.filter.Value()))
+	var visible []option
+	for _, o := range p.options {
+		if query == "" || strings.Contains(strings.ToLower(o.label), query) || strings.Contains(strings.ToLower(o.description), query) {
+			visible = append(visible, o)
+		}
+	}
+	return visible
+}
+
+// values returns the selected options in the order they are listed.
+func (p picker) values() []string {
+	var values []string
+	for _, o := range p.options {
+		if p.selected[o.value] {
+			values = append(values, o.value)
+		}
+	}
+	return values
+}
+
+func (p picker) View() string {
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	lines := []string{p.title, p.filter.View(), ""}
+	visible := p.visible()
+	start := 0
+	if p.index >= pickerRows {
+		start = p.index - pickerRows + 1
+	}
+	for i := start; i < len(visible) && i < start+pickerRows; i++ {
+		o := visible[i]
+		mark := "( ) "
+		if p.multi {
+			mark = "[ ] "
+		}
+		if p.selected[o.value] {
+			mark = strings.Replace(mark, " ", "x", 1)
+		}
+		line := mark + o.label
+		if o.description != "" {
+			line += gray.Render("  " + o.description)
+		}
+		if i == p.index {
+			line = common.PaneSelectedItemStyle().Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(visible) == 0 {
+		lines = append(lines, gray.Render("Nothing matches."))
+	}
+	help := "↑/↓ move · enter choose · esc cancel"
+	if p.multi {
+		help = "↑/↓ move · space toggle · enter/esc done"
+	}
+	lines = append(lines, "", gray.Render(help))
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(common.GrayColor()).
+		Padding(0, 1).
+		Render(strings.Join(lines, "\n"))
+}
+
+// sortedOptions orders options by label, ignoring case.
+func sortedOptions(options []option) []option {
+	sort.SliceStable(options, func(i, j int) bool {
+		return strings.ToLower(options[i].label) < strings.ToLower(options[j].label)
+	})
+	return options
+}
@@ -0,0 +1,175 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateDir is where GitHub looks for issue templates.
+const templateDir = ".github/ISSUE_TEMPLATE"
+
+// issueTemplate is an issue template, either markdown with a front matter or
+// an issue form, whose fields are turned into markdown sections.
+type issueTemplate struct {
+	Name        string     `yaml:"name"`
+	About       string     `yaml:"about"`
+	Description string     `yaml:"description"`
+	Title       string     `yaml:"title"`
+	Labels      stringList `yaml:"labels"`
+	Assignees   stringList `yaml:"assignees"`
+	Body        string     `yaml:"-"`
+}
+
+// stringList is a list in a template, which may also be written as a comma
+// separated string.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*l = nil
+		for _, value := range strings.Split(node.Value, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				*l = append(*l, value)
+			}
+		}
+		return nil
+	case yaml.SequenceNode:
+		var values []string
+		if err := node.Decode(&values); err != nil {
+			return err
+		}
+		*l = values
+		return nil
+	}
+	return fmt.Errorf("expected a list or a comma separated string")
+}
+
+// formField is an element of the body of an issue form.
+type formField struct {
+	Type       string `yaml:"type"`
+	Attributes struct {
+		Label       string        `yaml:"label"`
+		Description string        `yaml:"description"`
+		Value       string        `yaml:"value"`
+		Options     []interface{} `yaml:"options"`
+	} `yaml:"attributes"`
+}
+
+// parseTemplate reads a markdown template or an issue form.
+func parseTemplate(name string, data string) (issueTemplate, error) {
+	var template issueTemplate
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	if strings.HasSuffix(name, ".md") {
+		body := data
+		if rest := strings.TrimPrefix(data, "---\n"); rest != data {
+			var front string
+			if strings.HasPrefix(rest, "---") {
+				body = rest[3:]
+			} else if end := strings.Index(rest, "\n---"); end >= 0 {
+				front, body = rest[:end], rest[end+4:]
+			}
+			if err := yaml.Unmarshal([]byte(front), &template); err != nil {
+				return template, err
+			}
+		}
+		template.Body = strings.TrimLeft(body, "\n")
+	} else {
+		var form struct {
+			issueTemplate `yaml:",inline"`
+			Body          []formField `yaml:"body"`
+		}
+		if err := yaml.Unmarshal([]byte(data), &form); err != nil {
+			return template, err
+		}
+		template = form.issueTemplate
+		template.Body = formBody(form.Body)
+	}
+	if template.Name == "" {
+		template.Name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	if template.About == "" {
+		template.About = template.Description
+	}
+	return template, nil
+}
+
+// formBody writes the fields of an issue form as the markdown GitHub
+// submits for them.
+func formBody(fields []formField) string {
+	var sections []string
+	for _, field := range fields {
+		attributes := field.Attributes
+		switch field.Type {
+		case "markdown":
+			// Only shown while filling in the form on GitHub.
+			continue
+		case "checkboxes":
+			section := "### " + attributes.Label + "\n\n"
+			for _, o := range attributes.Options {
+				label := fmt.Sprint(o)
+				if m, ok := o.(map[string]interface{}); ok {
+					label = fmt.Sprint(m["label"])
+				}
+				section += "- [ ] " + label + "\n"
+			}
+			sections = append(sections, strings.TrimSuffix(section, "\n"))
+		default:
+			section := "### " + attributes.Label + "\n\n"
+			if attributes.Value != "" {
+				section += attributes.Value
+			} else if attributes.Description != "" {
+				section += "<!-- " + attributes.Description + " -->"
+			}
+			sections = append(sections, strings.TrimSuffix(section, "\n"))
+		}
+	}
+	return strings.Join(sections, "\n\n") + "\n"
+}
+
+// loadTemplates fetches the issue templates of the repository. A repository
+// without templates has none, which isn't an error. Templates that can't be
+// read are skipped, and why is returned along with the others.
+func (m Model) loadTemplates() ([]issueTemplate, []error, error) {
+	ctx := context.Background()
+	_, dir, resp, err := m.gh.Repositories.GetContents(ctx, m.owner(), m.repository.GetName(), templateDir, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	var templates []issueTemplate
+	var skipped []error
+	for _, entry := range dir {
+		name := entry.GetName()
+		if entry.GetType() != "file" || name == "config.yml" || name == "config.yaml" {
+			continue
+		}
+		if !strings.HasSuffix(name, ".md") && !strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		file, _, _, err := m.gh.Repositories.GetContents(ctx, m.owner(), m.repository.GetName(), entry.GetPath(), nil)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("could not load %s: %w", entry.GetPath(), err))
+			continue
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("could not decode %s: %w", entry.GetPath(), err))
+			continue
+		}
+		template, err := parseTemplate(name, content)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("could not parse %s: %w", entry.GetPath(), err))
+			continue
+		}
+		templates = append(templates, template)
+	}
+	return templates, skipped, nil
+}
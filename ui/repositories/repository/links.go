@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/git"
+)
+
+type repositorySymlinkResolvedMsg struct {
+	path string
+	dir  bool
+}
+type repositorySubmoduleLoadedMsg struct {
+	repository *github.Repository
+	sha        string
+}
+
+// resolveLinks fills in the symlink targets of a directory listing and
+// returns the URLs of the submodules in it, keyed by path. The contents API
+// only lists both as plain entries, so they are looked up separately.
+func (m Model) resolveLinks(contents []*github.RepositoryContent) map[string]string {
+	var urls map[string]string
+	for _, content := range contents {
+		switch {
+		case content.GetType() == "symlink":
+			// The blob of a symlink holds its target path.
+			target, _, err := m.gh.Git.GetBlobRaw(context.Background(), m.owner(), *m.repository.Name, content.GetSHA())
+			if err == nil {
+				content.Target = github.String(string(target))
+			}
+		case content.GetType() == "submodule" || (content.GetType() == "file" && content.DownloadURL == nil):
+			// Submodules may be listed as files without a download URL.
+			if urls == nil {
+				urls = m.loadGitModules()
+			}
+			if _, ok := urls[content.GetPath()]; ok {
+				content.Type = github.String("submodule")
+			}
+		}
+	}
+	return urls
+}
+
+// loadGitModules reads the submodule URLs from .gitmodules, keyed by path.
+func (m Model) loadGitModules() map[string]string {
+	urls := map[string]string{}
+	file, _, _, err := m.gh.Repositories.GetContents(
+		context.Background(),
+		m.owner(),
+		*m.repository.Name,
+		".gitmodules",
+		&github.RepositoryContentGetOptions{Ref: m.ref},
+	)
+	if err != nil || file == nil {
+		return urls
+	}
+	contents, err := file.GetContent()
+	if err != nil {
+		return urls
+	}
+	return parseGitModules(contents)
+}
+
+func parseGitModules(contents string) map[string]string {
+	urls := map[string]string{}
+	var modulePath, moduleURL string
+	flush := func() {
+		if modulePath != "" && moduleURL != "" {
+			urls[modulePath] = moduleURL
+		}
+		modulePath, moduleURL = "", ""
+	}
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			flush()
+			continue
+		}
+		key, value, ok := cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "path":
+			modulePath = strings.TrimSpace(value)
+		case "url":
+			moduleURL = strings.TrimSpace(value)
+		}
+	}
+	flush()
+	return urls
+}
+
+// symlinkTarget returns the repository path a symlink points to.
+func symlinkTarget(content *github.RepositoryContent) (string, error) {
+	target := content.GetTarget()
+	if target == "" {
+		return "", errors.New("the target of " + content.GetPath() + " is unknown")
+	}
+	if strings.HasPrefix(target, "/") {
+		return "", errors.New(content.GetPath() + " points outside the repository: " + target)
+	}
+	resolved := path.Clean(path.Join(parent(content.GetPath()), target))
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", errors.New(content.GetPath() + " points outside the repository: " + target)
+	}
+	if resolved == "." {
+		resolved = ""
+	}
+	return resolved, nil
+}
+
+// followSymlink looks up what the symlink points to, so it can be opened as a
+// file or revealed as a directory.
+func (m Model) followSymlink(content *github.RepositoryContent) tea.Cmd {
+	return func() tea.Msg {
+		target, err := symlinkTarget(content)
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		file, _, _, err := m.gh.Repositories.GetContents(
+			context.Background(),
+			m.owner(),
+			*m.repository.Name,
+			target,
+			&github.RepositoryContentGetOptions{Ref: m.ref},
+		)
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		return repositorySymlinkResolvedMsg{path: target, dir: file == nil}
+	}
+}
+
+// openSymlinkTarget shows the path a followed symlink resolved to.
+func openSymlinkTarget(m Model, msg repositorySymlinkResolvedMsg) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for _, dir := range m.files.reveal(msg.path) {
+		cmds = append(cmds, m.loadDirectory(dir))
+	}
+	if msg.dir {
+		if msg.path != "" && m.files.expand(msg.path) {
+			cmds = append(cmds, m.loadDirectory(msg.path))
+		}
+		m.paneIndex = 0
+		m.statusMsg = ""
+	} else {
+		cmds = append(cmds, m.loadFile(msg.path))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// loadSubmodule looks up the repository a submodule points to.
+func (m Model) loadSubmodule(content *github.RepositoryContent) tea.Cmd {
+	url := m.submoduleURLs[content.GetPath()]
+	return func() tea.Msg {
+		owner, name, ok := git.ParseRemoteURL(url, m.config.Host)
+		if !ok {
+			owner, name, ok = relativeSubmodule(m.owner(), m.repository.GetName(), url)
+		}
+		if !ok {
+			return repositoryErrorMsg(errors.New("cannot open submodule " + content.GetPath() + " from " + url))
+		}
+		repo, _, err := m.gh.Repositories.Get(context.Background(), owner, name)
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		return repositorySubmoduleLoadedMsg{repository: repo, sha: content.GetSHA()}
+	}
+}
+
+// relativeSubmodule resolves a relative submodule URL against the
+// repository owner/name, the way git resolves it against the remote.
+func relativeSubmodule(owner string, name string, url string) (string, string, bool) {
+	if !strings.HasPrefix(url, "./") && !strings.HasPrefix(url, "../") {
+		return "", "", false
+	}
+	parts := strings.Split(path.Join(owner, name, url), "/")
+	if len(parts) != 2 || parts[0] == ".." || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), true
+}
+
+// openSubmodule browses the submodule repository at its pinned commit.
+func openSubmodule(m Model, msg repositorySubmoduleLoadedMsg) (Model, tea.Cmd) {
+	submodule := NewModelAtRef(m.user, msg.repository, m.gh, m.config, msg.sha)
+	m.submodule = &submodule
+	m.statusMsg = ""
+	return m, submodule.Init()
+}
@@ -0,0 +1,26 @@
+package repository
+
+import "testing"
+
+func TestRelativeSubmodule(t *testing.T) {
+	tests := []struct {
+		url   string
+		owner string
+		name  string
+		ok    bool
+	}{
+		{"../lib.git", "owner", "lib", true},
+		{"../lib", "owner", "lib", true},
+		{"../../other-org/lib.git", "other-org", "lib", true},
+		{"./../lib.git", "owner", "lib", true},
+		{"./sub.git", "", "", false},
+		{"../../../lib.git", "", "", false},
+		{"lib.git", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, name, ok := relativeSubmodule("owner", "repo", tt.url)
+		if owner != tt.owner || name != tt.name || ok != tt.ok {
+			t.Errorf("%s: got %s/%s %t, want %s/%s %t", tt.url, owner, name, ok, tt.owner, tt.name, tt.ok)
+		}
+	}
+}
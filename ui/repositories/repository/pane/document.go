@@ -0,0 +1,406 @@
+package pane
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"ghtui/ghtui/ui/common"
+)
+
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeSearch
+	modeGoto
+)
+
+type match struct {
+	line  int
+	start int
+	end   int
+}
+
+// document is a line oriented text shown in a pane, which can be searched and
+// jumped around in.
+type document struct {
+	raw      []string
+	rendered []string
+	mode     mode
+	input    input.Model
+	query    string
+	matches  []match
+	match    int
+	message  string
+}
+
+// SetDocument shows raw text in the pane with line numbers, search and
+// go-to-line support. rendered holds the same text with styling applied, e.g.
+// syntax highlighting, and must have the same number of lines as raw.
+func (m *Model) SetDocument(raw string, rendered string) {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	rendered = strings.ReplaceAll(rendered, "\r\n", "\n")
+	doc := &document{
+		raw:      strings.Split(strings.TrimSuffix(raw, "\n"), "\n"),
+		rendered: strings.Split(strings.TrimSuffix(rendered, "\n"), "\n"),
+	}
+	if len(doc.rendered) != len(doc.raw) {
+		doc.rendered = doc.raw
+	}
+	m.document = doc
+	m.Viewport.Height = m.Height - 3
+	m.Viewport.YOffset = 0
+	m.render()
+}
+
+// UpdateDocument replaces the text of the shown document, keeping the scroll
+// position and the search. A document scrolled to the bottom stays there, so
+// growing text can be followed. Without a document it is the same as
+// SetDocument.
+func (m *Model) UpdateDocument(raw string, rendered string) {
+	if m.document == nil {
+		m.SetDocument(raw, rendered)
+		return
+	}
+	follow := m.Viewport.AtBottom()
+	offset := m.Viewport.YOffset
+
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	rendered = strings.ReplaceAll(rendered, "\r\n", "\n")
+	doc := m.document
+	doc.raw = strings.Split(strings.TrimSuffix(raw, "\n"), "\n")
+	doc.rendered = strings.Split(strings.TrimSuffix(rendered, "\n"), "\n")
+	if len(doc.rendered) != len(doc.raw) {
+		doc.rendered = doc.raw
+	}
+	doc.findMatches(doc.query)
+	if doc.match >= len(doc.matches) {
+		doc.match = 0
+	}
+	m.render()
+
+	if follow {
+		m.Viewport.GotoBottom()
+	} else {
+		m.Viewport.YOffset = offset
+		if m.Viewport.PastBottom() {
+			m.Viewport.GotoBottom()
+		}
+	}
+}
+
+// SetContent shows plain text in the pane, dropping any document.
+func (m *Model) SetContent(s string) {
+	m.document = nil
+	m.Viewport.Height = m.Height - 2
+	m.Viewport.SetContent(s)
+}
+
+// HasDocument reports whether the pane currently shows a document.
+func (m Model) HasDocument() bool {
+	return m.document != nil
+}
+
+// Prompting reports whether the pane is reading a search or go-to-line input,
+// in which case it should receive every key.
+func (m Model) Prompting() bool {
+	return m.document != nil && m.document.mode != modeNormal
+}
+
+// Line returns the 1-based line number shown at the top of the pane.
+func (m Model) Line() int {
+	return m.Viewport.YOffset + 1
+}
+
+// Lines returns the 1-based numbers of the first and last line of the
+// document shown in the pane.
+func (m Model) Lines() (int, int) {
+	first := m.Line()
+	last := m.Viewport.YOffset + m.Viewport.Height
+	if m.document != nil && last > len(m.document.raw) {
+		last = len(m.document.raw)
+	}
+	if last < first {
+		last = first
+	}
+	return first, last
+}
+
+// Update handles the navigation, search and go-to-line keys of a document.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.document == nil {
+		switch keyMsg.String() {
+		case "down":
+			m.Viewport.LineDown(1)
+		case "up":
+			m.Viewport.LineUp(1)
+		case "pgdown":
+			m.Viewport.ViewDown()
+		case "pgup":
+			m.Viewport.ViewUp()
+		}
+		return m, nil
+	}
+
+	doc := m.document
+	if doc.mode != modeNormal {
+		return m.updatePrompt(keyMsg)
+	}
+
+	switch keyMsg.String() {
+	case "down", "j":
+		m.Viewport.LineDown(1)
+	case "up", "k":
+		m.Viewport.LineUp(1)
+	case "pgdown", " ":
+		m.Viewport.ViewDown()
+	case "pgup":
+		m.Viewport.ViewUp()
+	case "home", "g":
+		m.Viewport.GotoTop()
+	case "end", "G":
+		m.Viewport.GotoBottom()
+	case "/":
+		return m, m.prompt(modeSearch, "/")
+	case ":":
+		return m, m.prompt(modeGoto, ":")
+	case "n":
+		m.nextMatch(1)
+	case "N":
+		m.nextMatch(-1)
+	}
+	return m, nil
+}
+
+func (m *Model) prompt(mode mode, prompt string) tea.Cmd {
+	doc := m.document
+	doc.mode = mode
+	doc.message = ""
+	doc.input = input.NewModel()
+	doc.input.Prompt = prompt
+	doc.input.Focus()
+	return input.Blink
+}
+
+func (m Model) updatePrompt(msg tea.KeyMsg) (Model, tea.Cmd) {
+	doc := m.document
+	switch msg.Type {
+	case tea.KeyEscape:
+		if doc.mode == modeSearch {
+			m.search("")
+		}
+		doc.mode = modeNormal
+		return m, nil
+	case tea.KeyEnter:
+		if doc.mode == modeGoto {
+			line, err := strconv.Atoi(strings.TrimSpace(doc.input.Value()))
+			if err != nil {
+				doc.message = "Not a line number: " + doc.input.Value()
+			} else {
+				m.GotoLine(line)
+			}
+		} else if len(doc.matches) == 0 && doc.query != "" {
+			doc.message = "Pattern not found: " + doc.query
+		}
+		doc.mode = modeNormal
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	previous := doc.input.Value()
+	doc.input, cmd = doc.input.Update(msg)
+	if doc.mode == modeSearch && doc.input.Value() != previous {
+		m.search(doc.input.Value())
+	}
+	return m, cmd
+}
+
+// GotoLine scrolls the document so the 1-based line is at the top.
+func (m *Model) GotoLine(line int) {
+	if m.document == nil {
+		return
+	}
+	if line < 1 {
+		line = 1
+	}
+	if line > len(m.document.raw) {
+		line = len(m.document.raw)
+	}
+	m.Viewport.YOffset = line - 1
+	if m.Viewport.PastBottom() {
+		m.Viewport.GotoBottom()
+	}
+}
+
+// search highlights every case-insensitive occurrence of query and scrolls to
+// the first one at or below the current line.
+func (m *Model) search(query string) {
+	doc := m.document
+	doc.findMatches(query)
+	doc.match = 0
+	for i, match := range doc.matches {
+		if match.line >= m.Viewport.YOffset {
+			doc.match = i
+			break
+		}
+	}
+	m.render()
+	m.showMatch()
+}
+
+// findMatches collects every case-insensitive occurrence of query. Runes are
+// compared folded one by one against the raw line, as lowering the whole line
+// can change its length and the offsets must slice the raw line.
+func (d *document) findMatches(query string) {
+	d.query = query
+	d.matches = nil
+	if query == "" {
+		return
+	}
+	needle := []rune(query)
+	for i, line := range d.raw {
+		for start := 0; start < len(line); {
+			if end, ok := matchAt(line, start, needle); ok {
+				d.matches = append(d.matches, match{line: i, start: start, end: end})
+				start = end
+				continue
+			}
+			_, size := utf8.DecodeRuneInString(line[start:])
+			start += size
+		}
+	}
+}
+
+// matchAt reports whether needle occurs in s at the byte offset start,
+// ignoring case, and where the occurrence ends.
+func matchAt(s string, start int, needle []rune) (int, bool) {
+	end := start
+	for _, want := range needle {
+		if end >= len(s) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if !equalFold(r, want) {
+			return 0, false
+		}
+		end += size
+	}
+	return end, true
+}
+
+// equalFold reports whether a and b are the same rune under Unicode case
+// folding.
+func equalFold(a rune, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) nextMatch(direction int) {
+	doc := m.document
+	if len(doc.matches) == 0 {
+		if doc.query != "" {
+			doc.message = "Pattern not found: " + doc.query
+		}
+		return
+	}
+	doc.match = (doc.match + direction + len(doc.matches)) % len(doc.matches)
+	m.render()
+	m.showMatch()
+}
+
+// showMatch scrolls the current match into view.
+func (m *Model) showMatch() {
+	doc := m.document
+	if len(doc.matches) == 0 {
+		return
+	}
+	line := doc.matches[doc.match].line
+	if line < m.Viewport.YOffset || line >= m.Viewport.YOffset+m.Viewport.Height {
+		m.GotoLine(line + 1 - m.Viewport.Height/2)
+	}
+}
+
+// render writes the document into the viewport with a line number gutter and
+// the search matches highlighted.
+func (m *Model) render() {
+	doc := m.document
+	width := len(strconv.Itoa(len(doc.raw)))
+	lineMatches := map[int][]int{}
+	for i, match := range doc.matches {
+		lineMatches[match.line] = append(lineMatches[match.line], i)
+	}
+
+	var b strings.Builder
+	for i := range doc.raw {
+		gutter := common.GutterStyle().Render(fmt.Sprintf("%*d ", width, i+1))
+		b.WriteString(gutter)
+		if indexes, ok := lineMatches[i]; ok {
+			b.WriteString(doc.highlightLine(i, indexes))
+		} else {
+			b.WriteString(doc.rendered[i])
+		}
+		if i < len(doc.raw)-1 {
+			b.WriteString("\n")
+		}
+	}
+	m.Viewport.SetContent(b.String())
+}
+
+// highlightLine renders a raw line with its matches highlighted. Syntax
+// highlighting is dropped for such lines so the match offsets stay valid.
+func (d document) highlightLine(line int, indexes []int) string {
+	raw := d.raw[line]
+	var b strings.Builder
+	last := 0
+	for _, i := range indexes {
+		match := d.matches[i]
+		b.WriteString(raw[last:match.start])
+		style := common.MatchStyle().Reverse(true)
+		if i == d.match {
+			style = common.PaneSelectedItemStyle()
+		}
+		b.WriteString(style.Render(raw[match.start:match.end]))
+		last = match.end
+	}
+	b.WriteString(raw[last:])
+	return b.String()
+}
+
+// footer renders the prompt or the position within the document.
+func (m Model) footer() string {
+	doc := m.document
+	switch doc.mode {
+	case modeSearch, modeGoto:
+		return doc.input.View()
+	}
+	if doc.message != "" {
+		return common.ErrorStyle().Render(doc.message)
+	}
+	s := fmt.Sprintf("%d/%d", m.Line(), len(doc.raw))
+	if doc.query != "" {
+		if len(doc.matches) > 0 {
+			s += fmt.Sprintf("  /%s (%d of %d)", doc.query, doc.match+1, len(doc.matches))
+		} else {
+			s += "  /" + doc.query + " (no matches)"
+		}
+	}
+	return lipgloss.NewStyle().Foreground(common.GrayColor()).Render(s)
+}
@@ -0,0 +1,42 @@
+package pane
+
+import "testing"
+
+func TestFindMatchesNonASCII(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		query string
+		want  []string
+	}{
+		{"ascii", "Foo foo FOO", "foo", []string{"Foo", "foo", "FOO"}},
+		{"invalid utf-8", "\xe9t\xe9 x", "x", []string{"x"}},
+		{"dotted capital i", "İstanbul x", "X", []string{"x"}},
+		{"kelvin sign", "K and k", "k", []string{"K", "k"}},
+		{"accents", "Éclair éclair", "éCLAIR", []string{"Éclair", "éclair"}},
+		{"no match", "abc", "abcd", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &document{raw: []string{tt.line}}
+			d.findMatches(tt.query)
+			if len(d.matches) != len(tt.want) {
+				t.Fatalf("got %d matches, want %d", len(d.matches), len(tt.want))
+			}
+			for i, m := range d.matches {
+				if got := tt.line[m.start:m.end]; got != tt.want[i] {
+					t.Errorf("match %d is %q, want %q", i, got, tt.want[i])
+				}
+			}
+			d.highlightLine(0, indexes(len(d.matches)))
+		})
+	}
+}
+
+func indexes(n int) []int {
+	var is []int
+	for i := 0; i < n; i++ {
+		is = append(is, i)
+	}
+	return is
+}
@@ -12,6 +12,8 @@ type Model struct {
 	Active   bool
 	Width    int
 	Height   int
+
+	document *document
 }
 
 func NewModel(width int, height int, active bool) Model {
@@ -33,5 +35,8 @@ func (m Model) View() string {
 	} else {
 		style = common.PaneInactiveStyle(m.Width, m.Height)
 	}
+	if m.document != nil {
+		return style.Render(lipgloss.JoinVertical(lipgloss.Left, m.Viewport.View(), m.footer()))
+	}
 	return style.Render(m.Viewport.View())
 }
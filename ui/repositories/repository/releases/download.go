@@ -0,0 +1,51 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// promptDownload asks where to save asset, suggesting its name in the working
+// directory.
+func promptDownload(m Model, asset *github.ReleaseAsset) (Model, tea.Cmd) {
+	if m.download.Active() {
+		m.statusMsg = "A download is already running."
+		return m, nil
+	}
+	cmd := m.download.Prompt(asset.GetName(), asset.GetName(), func(dest string, overwrite bool, p *common.DownloadProgress) error {
+		return m.downloadAsset(asset, dest, overwrite, p)
+	})
+	return m, cmd
+}
+
+// updateDownload passes key presses and progress to the download.
+func updateDownload(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var status string
+	m.download, cmd, status = m.download.Update(msg)
+	if status != "" {
+		m.statusMsg = status
+	}
+	return m, cmd
+}
+
+// downloadAsset saves asset to dest. Assets are served from a different host,
+// which the API redirects to.
+func (m Model) downloadAsset(asset *github.ReleaseAsset, dest string, overwrite bool, p *common.DownloadProgress) error {
+	p.TotalBytes = int64(asset.GetSize())
+	body, _, err := m.gh.Repositories.DownloadReleaseAsset(context.Background(), m.owner(), m.repository.GetName(), asset.GetID(), http.DefaultClient)
+	if err != nil {
+		return err
+	}
+	if body == nil {
+		return fmt.Errorf("GitHub returned no content for %s", asset.GetName())
+	}
+	defer body.Close()
+	return common.WriteFile(dest, overwrite, body, p)
+}
@@ -0,0 +1,339 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/config"
+	"ghtui/ghtui/ui/common"
+)
+
+// releaseNotesMsg carries the notes GitHub generated for a tag.
+type releaseNotesMsg struct {
+	tag   string
+	notes *github.RepositoryReleaseNotes
+	err   error
+}
+
+// releaseCreatedMsg reports a created release and the upload of its assets.
+// The release is set whenever it was created, even if an upload failed.
+type releaseCreatedMsg struct {
+	release  *github.RepositoryRelease
+	uploaded int
+	err      error
+}
+
+// The fields of the release form, in the order they are shown.
+const (
+	fieldTag = iota
+	fieldTarget
+	fieldName
+	fieldAssets
+	fieldDraft
+	fieldPrerelease
+	fieldCount
+)
+
+// releaseForm drafts a new release from a tag. The notes are generated by
+// GitHub from the changes since the previous release.
+type releaseForm struct {
+	tag        input.Model
+	target     input.Model
+	name       input.Model
+	assets     input.Model
+	draft      bool
+	prerelease bool
+	focus      int
+
+	// notesTag is the tag the notes were generated for.
+	notesTag   string
+	notes      string
+	generating bool
+	err        string
+}
+
+// openReleaseForm starts a release from the selected tag, or from a new tag
+// on the default branch.
+func openReleaseForm(m Model) (Model, tea.Cmd) {
+	tag := ""
+	if r, ok := m.selected(); ok && r.release == nil {
+		tag = r.tag.GetName()
+	}
+	form := releaseForm{draft: true}
+	form.tag = newFormInput(tag, "v1.0.0")
+	form.target = newFormInput(m.repository.GetDefaultBranch(), "")
+	form.name = newFormInput("", "the tag")
+	form.assets = newFormInput("", "comma separated local files")
+	form.setFocus(fieldTag)
+	m.form = &form
+	if tag == "" {
+		return m, input.Blink
+	}
+	return m, tea.Batch(input.Blink, m.generateNotes(&form))
+}
+
+func newFormInput(value string, placeholder string) input.Model {
+	model := input.NewModel()
+	model.Prompt = ""
+	model.Placeholder = placeholder
+	model.SetValue(value)
+	model.CursorEnd()
+	return model
+}
+
+func updateReleaseForm(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	form := m.form
+	switch msg.String() {
+	case "esc":
+		m.form = nil
+		return m, nil
+	case "tab", "down":
+		// Generate the notes once a tag has been typed in.
+		var cmd tea.Cmd
+		if form.focus == fieldTag && form.tagName() != form.notesTag {
+			cmd = m.generateNotes(form)
+		}
+		form.setFocus((form.focus + 1) % fieldCount)
+		return m, tea.Batch(input.Blink, cmd)
+	case "shift+tab", "up":
+		form.setFocus((form.focus + fieldCount - 1) % fieldCount)
+		return m, input.Blink
+	case "ctrl+g":
+		return m, m.generateNotes(form)
+	case "enter":
+		release, files, err := form.values()
+		if err != nil {
+			form.err = err.Error()
+			return m, nil
+		}
+		m.form = nil
+		m.statusMsg = "Creating release " + release.GetTagName() + "..."
+		return m, m.createRelease(release, files)
+	}
+
+	var cmd tea.Cmd
+	switch form.focus {
+	case fieldTag:
+		form.tag, cmd = form.tag.Update(msg)
+	case fieldTarget:
+		form.target, cmd = form.target.Update(msg)
+	case fieldName:
+		form.name, cmd = form.name.Update(msg)
+	case fieldAssets:
+		form.assets, cmd = form.assets.Update(msg)
+	case fieldDraft:
+		if msg.String() == " " || msg.String() == "x" {
+			form.draft = !form.draft
+		}
+	case fieldPrerelease:
+		if msg.String() == " " || msg.String() == "x" {
+			form.prerelease = !form.prerelease
+		}
+	}
+	return m, cmd
+}
+
+func (f *releaseForm) setFocus(focus int) {
+	f.tag.Blur()
+	f.target.Blur()
+	f.name.Blur()
+	f.assets.Blur()
+	f.focus = focus
+	switch focus {
+	case fieldTag:
+		f.tag.Focus()
+	case fieldTarget:
+		f.target.Focus()
+	case fieldName:
+		f.name.Focus()
+	case fieldAssets:
+		f.assets.Focus()
+	}
+}
+
+func (f releaseForm) tagName() string {
+	return strings.TrimSpace(f.tag.Value())
+}
+
+func (f *releaseForm) setNotes(msg releaseNotesMsg) {
+	if msg.tag != f.notesTag {
+		// Notes for another tag were asked for since.
+		return
+	}
+	f.generating = false
+	if msg.tag != f.tagName() {
+		// The tag was edited meanwhile; generate its notes when it's left.
+		f.notesTag = ""
+		return
+	}
+	if msg.err != nil {
+		f.err = "Could not generate the notes: " + msg.err.Error()
+		return
+	}
+	f.err = ""
+	f.notes = msg.notes.Body
+	if strings.TrimSpace(f.name.Value()) == "" {
+		f.name.SetValue(msg.notes.Name)
+	}
+}
+
+// values checks the form and returns the release to create and the files to
+// upload to it.
+func (f releaseForm) values() (*github.RepositoryRelease, []string, error) {
+	tag := f.tagName()
+	if tag == "" {
+		return nil, nil, fmt.Errorf("a tag is required")
+	}
+	release := &github.RepositoryRelease{
+		TagName:    github.String(tag),
+		Draft:      github.Bool(f.draft),
+		Prerelease: github.Bool(f.prerelease),
+	}
+	if target := strings.TrimSpace(f.target.Value()); target != "" {
+		release.TargetCommitish = github.String(target)
+	}
+	if name := strings.TrimSpace(f.name.Value()); name != "" {
+		release.Name = github.String(name)
+	}
+	if f.notesTag == tag && f.notes != "" {
+		release.Body = github.String(f.notes)
+	} else {
+		// Let GitHub write the notes if they weren't generated beforehand.
+		release.GenerateReleaseNotes = github.Bool(true)
+	}
+
+	var files []string
+	for _, p := range strings.Split(f.assets.Value(), ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		file, err := config.ExpandHome(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if info.IsDir() {
+			return nil, nil, fmt.Errorf("%s is a directory", p)
+		}
+		files = append(files, file)
+	}
+	return release, files, nil
+}
+
+func (f releaseForm) View() string {
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	field := func(focus int, label string, value string) string {
+		return common.FormField(f.focus == focus, label, value)
+	}
+
+	lines := []string{
+		"Draft a new release",
+		"",
+		field(fieldTag, "Tag *", f.tag.View()),
+		field(fieldTarget, "Target", f.target.View()),
+		common.FormField(false, "", gray.Render("the branch or commit to create the tag from, if it doesn't exist")),
+		field(fieldName, "Title", f.name.View()),
+		field(fieldAssets, "Assets", f.assets.View()),
+		field(fieldDraft, "Draft", common.Checkbox(f.draft)),
+		field(fieldPrerelease, "Pre-release", common.Checkbox(f.prerelease)),
+		"",
+	}
+
+	switch {
+	case f.generating:
+		lines = append(lines, gray.Render("Generating the release notes..."))
+	case f.notesTag != "" && f.notesTag == f.tagName():
+		lines = append(lines, "Release notes")
+		notes := strings.Split(common.RenderMarkdown(f.notes, 80), "\n")
+		if len(notes) > 15 {
+			notes = append(notes[:15], gray.Render(fmt.Sprintf("… %d more lines", len(notes)-15)))
+		}
+		lines = append(lines, notes...)
+	default:
+		lines = append(lines, gray.Render("The release notes will be generated from the changes since the previous release."))
+	}
+	lines = append(lines, "")
+	return common.FormView(lines, f.err, "tab/↑/↓ move · space toggle · ctrl+g generate notes · enter create · esc cancel")
+}
+
+// generateNotes asks GitHub for the notes of a release of the form's tag.
+func (m Model) generateNotes(form *releaseForm) tea.Cmd {
+	tag := form.tagName()
+	if tag == "" {
+		return nil
+	}
+	form.notesTag = tag
+	form.notes = ""
+	form.generating = true
+	opts := &github.GenerateNotesOptions{TagName: tag}
+	if target := strings.TrimSpace(form.target.Value()); target != "" {
+		opts.TargetCommitish = github.String(target)
+	}
+	return func() tea.Msg {
+		notes, _, err := m.gh.Repositories.GenerateReleaseNotes(context.Background(), m.owner(), m.repository.GetName(), opts)
+		return releaseNotesMsg{tag: tag, notes: notes, err: err}
+	}
+}
+
+// createRelease creates release and uploads files to it as assets.
+func (m Model) createRelease(release *github.RepositoryRelease, files []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		created, _, err := m.gh.Repositories.CreateRelease(ctx, m.owner(), m.repository.GetName(), release)
+		if err != nil {
+			return releaseCreatedMsg{err: err}
+		}
+		msg := releaseCreatedMsg{release: created}
+		for _, p := range files {
+			if msg.err = m.uploadAsset(ctx, created, p); msg.err != nil {
+				msg.err = fmt.Errorf("could not upload %s: %w", filepath.Base(p), msg.err)
+				break
+			}
+			msg.uploaded++
+		}
+		return msg
+	}
+}
+
+func (m Model) uploadAsset(ctx context.Context, release *github.RepositoryRelease, p string) error {
+	file, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, _, err = m.gh.Repositories.UploadReleaseAsset(ctx, m.owner(), m.repository.GetName(), release.GetID(), &github.UploadOptions{
+		Name: filepath.Base(p),
+	}, file)
+	return err
+}
+
+func updateReleaseCreated(m Model, msg releaseCreatedMsg) (Model, tea.Cmd) {
+	if msg.release == nil {
+		m.statusMsg = "Could not create the release: " + msg.err.Error()
+		return m, nil
+	}
+	kind := "release"
+	if msg.release.GetDraft() {
+		kind = "draft release"
+	}
+	m.statusMsg = fmt.Sprintf("Created %s %s", kind, releaseName(msg.release))
+	if msg.uploaded > 0 {
+		m.statusMsg += " with " + common.Plural(msg.uploaded, "asset")
+	}
+	m.statusMsg += "."
+	if msg.err != nil {
+		m.statusMsg += " " + msg.err.Error()
+	}
+	return m, m.loadReleases(msg.release.GetTagName())
+}
@@ -0,0 +1,417 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+	"github.com/muesli/reflow/truncate"
+
+	"ghtui/ghtui/browser"
+	"ghtui/ghtui/config"
+	"ghtui/ghtui/ui/common"
+	"ghtui/ghtui/ui/repositories/repository/pane"
+)
+
+type releasesLoadedMsg struct {
+	releases []*github.RepositoryRelease
+	tags     []*github.RepositoryTag
+	// selectTag is the tag of the release to select once loaded, if any.
+	selectTag string
+}
+type errorMsg error
+type statusMsg string
+
+// row is a release, one of its assets, or a tag that has no release, in the
+// left pane of the releases screen.
+type row struct {
+	release *github.RepositoryRelease
+	asset   *github.ReleaseAsset
+	tag     *github.RepositoryTag
+}
+
+type status int
+
+const (
+	statusLoading status = iota
+	statusReady
+)
+
+// Model lists the releases of a repository with their assets, followed by
+// the tags that have no release yet.
+type Model struct {
+	Done bool
+
+	repository *github.Repository
+	gh         *github.Client
+	config     *config.Config
+	spinner    spinner.Model
+	status     status
+	statusMsg  string
+	rows       []row
+	index      int
+	paneIndex  int
+	leftPane   pane.Model
+	rightPane  pane.Model
+	// shown is the row whose details are in the right pane.
+	shown    int
+	download common.Download
+	form     *releaseForm
+}
+
+func NewModel(repository *github.Repository, gh *github.Client, cfg *config.Config) Model {
+	width, height := common.ScreenSize()
+	baseWidth := width / 3
+	top, right, bottom, _ := common.AppStyle().GetPadding()
+	paneHeight := height - top - bottom
+	return Model{
+		repository: repository,
+		gh:         gh,
+		config:     cfg,
+		spinner:    common.NewSpinnerModel(),
+		status:     statusLoading,
+		leftPane:   pane.NewModel(baseWidth-right, paneHeight-3, true),
+		rightPane:  pane.NewModel(baseWidth*2-right, paneHeight-3, false),
+		shown:      -1,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.loadReleases(""), spinner.Tick)
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.download.Prompting() {
+			return updateDownload(m, msg)
+		}
+		if m.form != nil {
+			return updateReleaseForm(m, msg)
+		}
+		if m.paneIndex == 1 && m.rightPane.Prompting() {
+			m.rightPane, cmd = m.rightPane.Update(msg)
+			return m, cmd
+		}
+		switch msg.String() {
+		case "esc":
+			if m.paneIndex == 1 {
+				m.paneIndex = 0
+			} else {
+				m.Done = true
+			}
+		case "tab":
+			m.paneIndex ^= 1
+		case "up", "k":
+			if m.paneIndex == 0 {
+				m.move(-1)
+			} else {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		case "down", "j":
+			if m.paneIndex == 0 {
+				m.move(1)
+			} else {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		case "enter":
+			if m.paneIndex == 0 {
+				m.paneIndex = 1
+			}
+		case "r":
+			m.statusMsg = "Loading releases..."
+			return m, m.loadReleases("")
+		case "o":
+			if u := m.selectedURL(); u != "" {
+				return m, browser.OpenCmd(m.config, u)
+			}
+		case "y":
+			if u := m.permalink(); u != "" {
+				return m, browser.CopyCmd(u)
+			}
+		case "d":
+			if r, ok := m.selected(); ok && r.asset != nil {
+				return promptDownload(m, r.asset)
+			}
+			m.statusMsg = "Select an asset to download it."
+		case "N":
+			return openReleaseForm(m)
+		default:
+			if m.paneIndex == 1 {
+				m.rightPane, cmd = m.rightPane.Update(msg)
+			}
+		}
+	case spinner.TickMsg:
+		m.spinner, cmd = m.spinner.Update(msg)
+	case releasesLoadedMsg:
+		m.status = statusReady
+		// Keep the report of the release that was just created.
+		if msg.selectTag == "" {
+			m.statusMsg = ""
+		}
+		m.setRows(msg.releases, msg.tags, msg.selectTag)
+	case releaseNotesMsg:
+		if m.form != nil {
+			m.form.setNotes(msg)
+		}
+	case releaseCreatedMsg:
+		return updateReleaseCreated(m, msg)
+	case common.DownloadProgressMsg:
+		return updateDownload(m, msg)
+	case statusMsg:
+		m.statusMsg = string(msg)
+	case browser.OpenedMsg:
+		m.statusMsg = msg.Status()
+	case browser.CopiedMsg:
+		m.statusMsg = msg.Status()
+	case errorMsg:
+		m.status = statusReady
+		m.statusMsg = msg.Error()
+	}
+	m.showSelected()
+	m.leftPane.Active = m.paneIndex == 0
+	m.rightPane.Active = m.paneIndex == 1
+	return m, cmd
+}
+
+func (m Model) View() string {
+	title := common.ListTitleStyle().Render(m.repository.GetName() + " · Releases")
+	if m.status == statusLoading {
+		return common.AppStyle().Render(title + "\n\n" + m.spinner.View() + " Loading releases...")
+	}
+	if m.form != nil {
+		return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, title, "", m.form.View()))
+	}
+
+	m.leftPane.Viewport.SetContent(m.rowsView())
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, m.leftPane.View(), m.rightPane.View())
+	statusBar := lipgloss.NewStyle().Foreground(common.GrayColor()).
+		Render("d download asset · N new release · r refresh · o open in browser · y copy link · tab switch pane · esc back")
+	if download := m.download.View(); download != "" {
+		statusBar = download
+	} else if m.statusMsg != "" {
+		statusBar = m.statusMsg
+	}
+	return common.AppStyle().Render(lipgloss.JoinVertical(lipgloss.Left, title, panes, statusBar))
+}
+
+func (m Model) rowsView() string {
+	if len(m.rows) == 0 {
+		return "No releases or tags yet."
+	}
+	width := uint(m.leftPane.Viewport.Width)
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	var lines []string
+	for i, r := range m.rows {
+		var line string
+		switch {
+		case r.asset != nil:
+			line = "  " + r.asset.GetName() + gray.Render(" "+common.FormatBytes(int64(r.asset.GetSize())))
+		case r.release != nil:
+			line = releaseName(r.release)
+			if badges := releaseBadges(r.release); badges != "" {
+				line += gray.Render(" " + badges)
+			}
+		default:
+			line = r.tag.GetName() + gray.Render(" tag")
+		}
+		line = truncate.StringWithTail(line, width, "…")
+		if i == m.index {
+			line = common.PaneSelectedItemStyle().Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// setRows lists every release with its assets, then the tags without a
+// release, keeping the selection on the same row where possible.
+func (m *Model) setRows(releases []*github.RepositoryRelease, tags []*github.RepositoryTag, selectTag string) {
+	previous, _ := m.selected()
+	m.rows = nil
+	m.index = 0
+	released := map[string]bool{}
+	for _, release := range releases {
+		released[release.GetTagName()] = true
+		if (selectTag != "" && release.GetTagName() == selectTag) ||
+			(selectTag == "" && previous.release != nil && previous.asset == nil && release.GetID() == previous.release.GetID()) {
+			m.index = len(m.rows)
+		}
+		m.rows = append(m.rows, row{release: release})
+		for _, asset := range release.Assets {
+			if selectTag == "" && previous.asset != nil && asset.GetID() == previous.asset.GetID() {
+				m.index = len(m.rows)
+			}
+			m.rows = append(m.rows, row{release: release, asset: asset})
+		}
+	}
+	for _, tag := range tags {
+		if released[tag.GetName()] {
+			continue
+		}
+		if selectTag == "" && previous.tag != nil && tag.GetName() == previous.tag.GetName() {
+			m.index = len(m.rows)
+		}
+		m.rows = append(m.rows, row{tag: tag})
+	}
+	m.shown = -1
+	m.move(0)
+}
+
+func (m *Model) move(delta int) {
+	m.index += delta
+	if m.index >= len(m.rows) {
+		m.index = len(m.rows) - 1
+	}
+	if m.index < 0 {
+		m.index = 0
+	}
+	if m.index < m.leftPane.Viewport.YOffset {
+		m.leftPane.Viewport.YOffset = m.index
+	} else if height := m.leftPane.Viewport.Height; height > 0 && m.index >= m.leftPane.Viewport.YOffset+height {
+		m.leftPane.Viewport.YOffset = m.index - height + 1
+	}
+}
+
+func (m Model) selected() (row, bool) {
+	if m.index < 0 || m.index >= len(m.rows) {
+		return row{}, false
+	}
+	return m.rows[m.index], true
+}
+
+// showSelected puts the details of the selected row into the right pane,
+// unless they are already shown there.
+func (m *Model) showSelected() {
+	if m.status != statusReady || m.index == m.shown {
+		return
+	}
+	m.shown = m.index
+	r, ok := m.selected()
+	if !ok {
+		m.rightPane.SetContent("Press N to draft the first release.")
+		return
+	}
+	m.rightPane.SetContent(m.details(r))
+	m.rightPane.Viewport.GotoTop()
+}
+
+// details describes a release, with its assets and rendered notes, or a tag
+// that has no release.
+func (m Model) details(r row) string {
+	gray := lipgloss.NewStyle().Foreground(common.GrayColor())
+	if r.release == nil {
+		return strings.Join([]string{
+			lipgloss.NewStyle().Bold(true).Render(r.tag.GetName()),
+			gray.Render("commit " + common.ShortSHA(r.tag.GetCommit().GetSHA())),
+			"",
+			"This tag has no release. Press N to draft one from it.",
+		}, "\n")
+	}
+
+	release := r.release
+	lines := []string{lipgloss.NewStyle().Bold(true).Render(releaseName(release))}
+	facts := []string{"tag " + release.GetTagName()}
+	if badges := releaseBadges(release); badges != "" {
+		facts = append(facts, badges)
+	}
+	if author := release.GetAuthor().GetLogin(); author != "" {
+		facts = append(facts, "by "+author)
+	}
+	if release.PublishedAt != nil {
+		facts = append(facts, "published "+common.RelativeTime(release.GetPublishedAt().Time))
+	} else {
+		facts = append(facts, "created "+common.RelativeTime(release.GetCreatedAt().Time))
+	}
+	lines = append(lines, gray.Render(strings.Join(facts, " · ")), "")
+
+	if len(release.Assets) > 0 {
+		lines = append(lines, "Assets")
+		for _, asset := range release.Assets {
+			line := fmt.Sprintf("  %-40s %10s  %s",
+				truncate.StringWithTail(asset.GetName(), 40, "…"),
+				common.FormatBytes(int64(asset.GetSize())),
+				common.Plural(asset.GetDownloadCount(), "download"),
+			)
+			if r.asset != nil && asset.GetID() == r.asset.GetID() {
+				line = common.PaneSelectedItemStyle().Render(line)
+			}
+			lines = append(lines, line)
+		}
+		lines = append(lines, "")
+	}
+
+	if strings.TrimSpace(release.GetBody()) == "" {
+		lines = append(lines, gray.Render("No release notes."))
+	} else {
+		lines = append(lines, common.RenderMarkdown(release.GetBody(), m.rightPane.Viewport.Width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) selectedURL() string {
+	r, ok := m.selected()
+	switch {
+	case !ok:
+		return ""
+	case r.asset != nil:
+		return r.asset.GetBrowserDownloadURL()
+	case r.release != nil:
+		return r.release.GetHTMLURL()
+	default:
+		return m.repository.GetHTMLURL() + "/tree/" + r.tag.GetName()
+	}
+}
+
+// permalink is the URL of the selected row like selectedURL, but a tag
+// without a release is pinned to its commit.
+func (m Model) permalink() string {
+	if r, ok := m.selected(); ok && r.release == nil && r.asset == nil {
+		return m.repository.GetHTMLURL() + "/tree/" + r.tag.GetCommit().GetSHA()
+	}
+	return m.selectedURL()
+}
+
+func (m Model) owner() string {
+	return m.repository.GetOwner().GetLogin()
+}
+
+// loadReleases fetches the latest releases and tags, selecting the release
+// of selectTag once they are shown.
+func (m Model) loadReleases(selectTag string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		releases, _, err := m.gh.Repositories.ListReleases(ctx, m.owner(), m.repository.GetName(), &github.ListOptions{PerPage: 50})
+		if err != nil {
+			return errorMsg(err)
+		}
+		tags, _, err := m.gh.Repositories.ListTags(ctx, m.owner(), m.repository.GetName(), &github.ListOptions{PerPage: 100})
+		if err != nil {
+			return errorMsg(err)
+		}
+		return releasesLoadedMsg{releases: releases, tags: tags, selectTag: selectTag}
+	}
+}
+
+func releaseName(release *github.RepositoryRelease) string {
+	if name := release.GetName(); name != "" {
+		return name
+	}
+	return release.GetTagName()
+}
+
+func releaseBadges(release *github.RepositoryRelease) string {
+	var badges []string
+	if release.GetDraft() {
+		badges = append(badges, "draft")
+	}
+	if release.GetPrerelease() {
+		badges = append(badges, "pre-release")
+	}
+	return strings.Join(badges, " ")
+}
@@ -2,21 +2,39 @@ package repository
 
 import (
 	"context"
-	"encoding/base64"
-	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	input "github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/google/go-github/v39/github"
 
+	"ghtui/ghtui/browser"
+	"ghtui/ghtui/config"
 	"ghtui/ghtui/ui/common"
+	"ghtui/ghtui/ui/repositories/repository/actions"
+	"ghtui/ghtui/ui/repositories/repository/issues"
 	"ghtui/ghtui/ui/repositories/repository/pane"
+	"ghtui/ghtui/ui/repositories/repository/releases"
 )
 
-type repositoryFilesLoadedMsg []*github.RepositoryContent
-type repositoryFileLoadedMsg *github.RepositoryContent
+type repositoryFilesLoadedMsg struct {
+	dir        string
+	contents   []*github.RepositoryContent
+	submodules map[string]string
+}
+type repositoryFileLoadedMsg struct {
+	content   *github.RepositoryContent
+	data      []byte
+	lfsObject bool
+}
+type repositoryTreeLoadedMsg struct {
+	ref       string
+	entries   []*github.TreeEntry
+	truncated bool
+}
 type repositoryErrorMsg error
+type repositoryStatusMsg string
 type status int
 
 const (
@@ -34,55 +52,205 @@ type Model struct {
 	spinner          spinner.Model
 	status           status
 	paneIndex        int
-	fileIndex        int
 	gh               *github.Client
 	statusMsg        string
-	contents         []*github.RepositoryContent
+	files            fileTree
 	selectedContents *github.RepositoryContent
-	path             string
+	selectedKind     fileKind
 	leftPane         pane.Model
 	rightPane        pane.Model
-	title            string
-	depth            int
+	ref              string
+	tree             []*github.TreeEntry
+	treeRef          string
+	finding          bool
+	finder           finderModel
+	submodule        *Model
+	submoduleURLs    map[string]string
+	download         common.Download
+	config           *config.Config
+	actions          *actions.Model
+	releases         *releases.Model
+	// issues lists the issues or the pull requests.
+	issues *issues.Model
+	// screenOnly is set when the repository was opened for its actions,
+	// releases, issues or pull requests, so leaving them leaves the
+	// repository too.
+	screenOnly bool
+	checks     checksModel
+	// file is the file shown in the right pane, kept to show it again.
+	file *repositoryFileLoadedMsg
 }
 
-func NewModel(user *github.User, repository *github.Repository, gh *github.Client) Model {
+func NewModel(user *github.User, repository *github.Repository, gh *github.Client, cfg *config.Config) Model {
+	return NewModelAtRef(user, repository, gh, cfg, repository.GetDefaultBranch())
+}
+
+// NewModelAtRef browses the repository at the given branch, tag or commit.
+func NewModelAtRef(user *github.User, repository *github.Repository, gh *github.Client, cfg *config.Config, ref string) Model {
 	return Model{
-		Done:       false,
-		Quit:       false,
-		user:       user,
-		repository: repository,
-		spinner:    common.NewSpinnerModel(),
-		status:     statusInit,
-		paneIndex:  0,
-		fileIndex:  0,
-		gh:         gh,
-		path:       "",
-		depth:      0,
+		Done:          false,
+		Quit:          false,
+		user:          user,
+		repository:    repository,
+		spinner:       common.NewSpinnerModel(),
+		status:        statusInit,
+		paneIndex:     0,
+		gh:            gh,
+		files:         newFileTree(),
+		submoduleURLs: map[string]string{},
+		ref:           ref,
+		config:        cfg,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.loadRepositoryContents, spinner.Tick)
+	return tea.Batch(m.loadDirectory(""), m.loadChecks, spinner.Tick)
+}
+
+// OpenActions shows the workflow runs of the repository. Leaving them leaves
+// the repository as well.
+func (m Model) OpenActions() (Model, tea.Cmd) {
+	m.screenOnly = true
+	return openActions(m)
+}
+
+func openActions(m Model) (Model, tea.Cmd) {
+	model := actions.NewModel(m.repository, m.gh, m.config)
+	m.actions = &model
+	return m, model.Init()
+}
+
+// OpenReleases shows the releases of the repository. Leaving them leaves the
+// repository as well.
+func (m Model) OpenReleases() (Model, tea.Cmd) {
+	m.screenOnly = true
+	return openReleases(m)
+}
+
+func openReleases(m Model) (Model, tea.Cmd) {
+	model := releases.NewModel(m.repository, m.gh, m.config)
+	m.releases = &model
+	return m, model.Init()
+}
+
+// OpenIssues shows the issues of the repository. Leaving them leaves the
+// repository as well.
+func (m Model) OpenIssues() (Model, tea.Cmd) {
+	m.screenOnly = true
+	return openIssues(m)
+}
+
+func openIssues(m Model) (Model, tea.Cmd) {
+	model := issues.NewModel(m.user, m.repository, m.gh, m.config)
+	m.issues = &model
+	return m, model.Init()
+}
+
+// OpenPullRequests shows the pull requests of the repository. Leaving them
+// leaves the repository as well.
+func (m Model) OpenPullRequests() (Model, tea.Cmd) {
+	m.screenOnly = true
+	return openPullRequests(m)
+}
+
+func openPullRequests(m Model) (Model, tea.Cmd) {
+	model := issues.NewPullsModel(m.user, m.repository, m.gh, m.config)
+	m.issues = &model
+	return m, model.Init()
 }
 
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if m.actions != nil {
+		model, cmd := m.actions.Update(msg)
+		if model.Done {
+			m.actions = nil
+			return m.leaveScreen()
+		}
+		m.actions = &model
+		return m.updateBehindScreen(msg, cmd)
+	}
+	if m.releases != nil {
+		model, cmd := m.releases.Update(msg)
+		if model.Done {
+			m.releases = nil
+			return m.leaveScreen()
+		}
+		m.releases = &model
+		return m.updateBehindScreen(msg, cmd)
+	}
+	if m.issues != nil {
+		model, cmd := m.issues.Update(msg)
+		if model.Done {
+			m.issues = nil
+			return m.leaveScreen()
+		}
+		m.issues = &model
+		return m.updateBehindScreen(msg, cmd)
+	}
+	return m.updateRepository(msg)
+}
+
+// leaveScreen goes back to the files from the actions, releases or issues,
+// or leaves the repository if it was opened for them.
+func (m Model) leaveScreen() (Model, tea.Cmd) {
+	m.Done = m.screenOnly
+	m.screenOnly = false
+	return m, nil
+}
+
+// updateBehindScreen passes the messages a screen opened over the files
+// doesn't own on to the repository, so loads started before the screen was
+// opened still land here.
+func (m Model) updateBehindScreen(msg tea.Msg, cmd tea.Cmd) (Model, tea.Cmd) {
+	switch msg.(type) {
+	case tea.KeyMsg, spinner.TickMsg, browser.OpenedMsg, browser.CopiedMsg:
+		return m, cmd
+	}
+	var own tea.Cmd
+	m, own = m.updateRepository(msg)
+	return m, common.BatchCommands(cmd, own)
+}
+
+func (m Model) updateRepository(msg tea.Msg) (Model, tea.Cmd) {
+	if m.submodule != nil {
+		submodule, cmd := m.submodule.Update(msg)
+		if submodule.Done {
+			m.submodule = nil
+			return m, nil
+		}
+		m.submodule = &submodule
+		return m, cmd
+	}
+
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		// TODO: do something?
 	case tea.KeyMsg:
+		if m.finding {
+			return updateFinder(m, msg)
+		}
+		if m.download.Prompting() {
+			return updateDownload(m, msg)
+		}
+		if m.checks.open {
+			return updateChecks(m, msg)
+		}
+		if m.paneIndex == 1 && m.rightPane.Prompting() {
+			m.rightPane, cmd = m.rightPane.Update(msg)
+			return m, cmd
+		}
 		switch msg.Type {
 		case tea.KeyEscape:
 			if m.paneIndex == 0 {
-				if m.depth == 0 {
+				row, ok := m.files.current()
+				if !ok || parent(row.content.GetPath()) == "" {
 					m.Done = true
 					return m, nil
-				} else {
-					m.depth -= 1
-					m.path = m.path[0:strings.LastIndex(m.path, "/")]
-					return m, common.Cmd(m.loadRepositoryContents())
 				}
+				dir := parent(row.content.GetPath())
+				m.files.collapse(dir)
+				m.files.selectPath(dir)
 			} else if m.paneIndex == 1 {
 				m.selectedContents = nil
 				m.paneIndex = 0
@@ -93,55 +261,124 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				return loadRepositoryContent(m)
 			case "down":
 				if m.paneIndex == 0 {
-					if m.fileIndex < len(m.contents)-1 {
-						m.fileIndex += 1
-					}
+					m.files.down()
 				} else if m.paneIndex == 1 {
-					m.rightPane.Viewport.LineDown(1)
+					m.rightPane, cmd = m.rightPane.Update(msg)
 				}
 			case "up":
 				if m.paneIndex == 0 {
-					if m.fileIndex > 0 {
-						m.fileIndex -= 1
-					}
+					m.files.up()
 				} else if m.paneIndex == 1 {
-					m.rightPane.Viewport.LineUp(1)
+					m.rightPane, cmd = m.rightPane.Update(msg)
+				}
+			case "right", "l":
+				if m.paneIndex == 0 {
+					return expandSelected(m)
+				}
+			case "left", "h":
+				if m.paneIndex == 0 {
+					collapseSelected(&m)
+				}
+			case "r":
+				if m.paneIndex == 0 {
+					return reloadFiles(m)
 				}
 			case "tab":
 				// Switch between 0 and 1
 				m.paneIndex ^= 1
+			case "t":
+				if m.treeRef == m.ref {
+					return openFinder(m)
+				}
+				m.statusMsg = "Loading file tree..."
+				return m, m.loadTree
+			case "d":
+				if row, ok := m.files.current(); ok && m.paneIndex == 0 {
+					if row.content.GetType() == "file" || row.content.GetType() == "dir" {
+						return promptDownload(m, downloadTarget{content: row.content})
+					}
+				}
+			case "D":
+				return promptDownload(m, downloadTarget{})
+			case "a":
+				return openActions(m)
+			case "R":
+				return openReleases(m)
+			case "i":
+				return openIssues(m)
+			case "p":
+				return openPullRequests(m)
+			case "c":
+				return openChecks(m)
+			case "o":
+				return m, m.openInBrowser()
+			case "y":
+				return m, m.copyPermalink()
+			case "f":
+				if m.paneIndex == 1 && m.selectedContents != nil && m.selectedKind == fileLFSPointer {
+					m.statusMsg = "Fetching LFS object for " + m.selectedContents.GetName() + "..."
+					return m, m.loadLFSObject(m.selectedContents)
+				}
+			default:
+				if m.paneIndex == 1 {
+					m.rightPane, cmd = m.rightPane.Update(msg)
+				}
 			}
 		}
 	case spinner.TickMsg:
 		m.spinner, cmd = m.spinner.Update(msg)
 	case repositoryFilesLoadedMsg:
-		m.fileIndex = 0
-		m.status = statusReady
-		m.contents = msg
-		titleStyle := common.ListTitleStyle()
-		m.title = titleStyle.Render(*m.repository.Name)
-		width, height := common.ScreenSize()
-		baseWidth := width / 4
-		top, right, bottom, _ := common.AppStyle().GetPadding()
-		paneHeight := height - top - bottom
-		m.leftPane = pane.NewModel(baseWidth-right, paneHeight-3, m.paneIndex == 0)
-		m.rightPane = pane.NewModel(baseWidth*3-right, paneHeight-3, m.paneIndex == 1)
-		m.leftPane.Viewport.SetContent(m.getFileList())
-		m.rightPane.Viewport.SetContent("Use the arrow keys to navigate. Press enter to select a file/folder.")
+		if m.status != statusReady {
+			m.status = statusReady
+			width, height := common.ScreenSize()
+			baseWidth := width / 4
+			top, right, bottom, _ := common.AppStyle().GetPadding()
+			paneHeight := height - top - bottom
+			m.leftPane = pane.NewModel(baseWidth-right, paneHeight-3, m.paneIndex == 0)
+			m.rightPane = pane.NewModel(baseWidth*3-right, paneHeight-3, m.paneIndex == 1)
+			m.rightPane.SetContent("Use the arrow keys to navigate. Press enter to open a file or expand a folder.")
+		}
+		m.statusMsg = ""
+		for p, url := range msg.submodules {
+			m.submoduleURLs[p] = url
+		}
+		m.files.setDirectory(msg.dir, msg.contents)
 	case repositoryFileLoadedMsg:
 		m.status = statusReady
 		m.paneIndex = 1
-		m.selectedContents = msg
-		bytes, _ := base64.StdEncoding.DecodeString(*m.selectedContents.Content)
-		m.rightPane.Viewport.SetContent(common.Highlight(*m.selectedContents.Name, string(bytes)))
+		m.selectedContents = msg.content
+		m.file = &msg
+		m.checks.open = false
+		m = showFile(m, msg)
+	case repositoryTreeLoadedMsg:
+		m.tree = msg.entries
+		m.treeRef = msg.ref
+		m.statusMsg = ""
+		if msg.truncated {
+			m.statusMsg = "The file tree is too large and was truncated by GitHub."
+		}
+		if msg.ref == m.ref {
+			return openFinder(m)
+		}
+	case repositorySymlinkResolvedMsg:
+		return openSymlinkTarget(m, msg)
+	case repositorySubmoduleLoadedMsg:
+		return openSubmodule(m, msg)
+	case repositoryStatusMsg:
+		m.statusMsg = string(msg)
+	case browser.OpenedMsg:
+		m.statusMsg = msg.Status()
+	case checksLoadedMsg:
+		m = updateChecksLoaded(m, msg)
+	case common.DownloadProgressMsg:
+		return updateDownload(m, msg)
 	case repositoryErrorMsg:
 		m.status = statusReady
 		m.statusMsg = msg.Error()
 	}
 	var childCmd tea.Cmd
 	m, childCmd = updateChildren(m, msg)
-	common.BatchCommands(cmd, childCmd)
-	return m, cmd
+	return m, common.BatchCommands(cmd, childCmd)
 }
 
 func updateChildren(m Model, msg tea.Msg) (Model, tea.Cmd) {
@@ -152,12 +389,25 @@ func updateChildren(m Model, msg tea.Msg) (Model, tea.Cmd) {
 	case statusReady:
 		m.leftPane.Active = m.paneIndex == 0
 		m.rightPane.Active = m.paneIndex == 1
-		// do nothing?
+		m.syncFileList()
 	}
 	return m, cmd
 }
 
 func (m Model) View() string {
+	if m.actions != nil {
+		return m.actions.View()
+	}
+	if m.releases != nil {
+		return m.releases.View()
+	}
+	if m.issues != nil {
+		return m.issues.View()
+	}
+	if m.submodule != nil {
+		return m.submodule.View()
+	}
+
 	s := ""
 	switch m.status {
 	case statusInit:
@@ -165,77 +415,170 @@ func (m Model) View() string {
 	case statusLoading:
 		s += m.spinner.View() + " Loading " + *m.repository.Name + "..."
 	case statusReady:
-		m.leftPane.Viewport.SetContent(m.getFileList())
+		m.syncFileList()
+		if m.finding {
+			m.rightPane.SetContent(m.finder.View(m.rightPane.Viewport.Height))
+		} else if m.checks.open {
+			m.rightPane.SetContent(m.checks.View(m.rightPane.Viewport.Width))
+		}
 		panes := lipgloss.JoinHorizontal(lipgloss.Top, m.leftPane.View(), m.rightPane.View())
 		var statusBar string
-		if m.statusMsg != "" {
+		if download := m.download.View(); download != "" {
+			statusBar = download
+		} else if m.statusMsg != "" {
 			statusBar = m.spinner.View() + " " + m.statusMsg
 		}
-		s += lipgloss.JoinVertical(lipgloss.Top, m.title, panes, statusBar)
+		root := *m.repository.Name
+		if m.ref != m.repository.GetDefaultBranch() {
+			root += "@" + refLabel(m.ref)
+		}
+		title := common.ListTitleStyle().Render(m.files.breadcrumbs(root))
+		s += lipgloss.JoinVertical(lipgloss.Top, title, panes, statusBar)
 	}
 	s += string(rune(m.status))
 	return s
 }
 
-func (m Model) loadRepositoryContents() tea.Msg {
-	if m.status == statusLoading {
-		return spinner.Tick
-	}
+// loadDirectory fetches the contents of dir for the current ref.
+func (m Model) loadDirectory(dir string) tea.Cmd {
+	return func() tea.Msg {
+		opts := &github.RepositoryContentGetOptions{Ref: m.ref}
+		_, directory, _, err := m.gh.Repositories.GetContents(context.Background(), m.owner(), *m.repository.Name, dir, opts)
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
 
-	m.status = statusLoading
-	m.statusMsg = "Loading repository contents..."
-	opts := &github.RepositoryContentGetOptions{Ref: *m.repository.DefaultBranch}
-	_, directory, _, err := m.gh.Repositories.GetContents(context.Background(), *m.user.Login, *m.repository.Name, m.path, opts)
-	if err != nil {
-		return repositoryErrorMsg(err)
+		submodules := m.resolveLinks(directory)
+		return repositoryFilesLoadedMsg{dir: dir, contents: directory, submodules: submodules}
 	}
-
-	return repositoryFilesLoadedMsg(directory)
 }
 
 func loadRepositoryContent(m Model) (Model, tea.Cmd) {
-	contents := m.contents[m.fileIndex]
-	m.statusMsg = "Loading " + *contents.Name + " " + *contents.Type
+	row, ok := m.files.current()
+	if !ok {
+		return m, nil
+	}
+	contents := row.content
 	if *contents.Type == "dir" {
-		m.path = m.path + "/" + *contents.Name
-		m.depth += 1
-		return m, common.Cmd(m.loadRepositoryContents())
+		if m.files.expanded[contents.GetPath()] {
+			m.files.collapse(contents.GetPath())
+			return m, nil
+		}
+		return expandSelected(m)
 	} else if *contents.Type == "file" {
 		m.statusMsg = "Loading " + *contents.Name + "..."
-		file, _, _, err := m.gh.Repositories.GetContents(
-			context.Background(),
-			*m.user.Login,
-			*m.repository.Name,
-			m.path+"/"+*contents.Name,
-			&github.RepositoryContentGetOptions{
-				Ref: *m.repository.DefaultBranch,
-			},
-		)
-		if err != nil {
-			return m, common.Cmd(repositoryErrorMsg(err))
-		}
-		return m, common.Cmd(repositoryFileLoadedMsg(file))
+		return m, m.loadFile(contents.GetPath())
+	} else if *contents.Type == "symlink" {
+		m.statusMsg = "Following " + *contents.Name + "..."
+		return m, m.followSymlink(contents)
+	} else if *contents.Type == "submodule" {
+		m.statusMsg = "Opening submodule " + *contents.Name + "..."
+		return m, m.loadSubmodule(contents)
 	} else {
 		// This should never happen.
 		return m, nil
 	}
 }
 
-func (m Model) getFileList() string {
-	pane := ""
-	for i, content := range m.contents {
-		line := ""
-		if *content.Type == "file" {
-			line += "📄"
-		} else if *content.Type == "dir" {
-			line += "📁"
+func expandSelected(m Model) (Model, tea.Cmd) {
+	row, ok := m.files.current()
+	if !ok || row.content.GetType() != "dir" {
+		return m, nil
+	}
+	dir := row.content.GetPath()
+	if m.files.expand(dir) {
+		m.statusMsg = "Loading " + dir + "..."
+		return m, m.loadDirectory(dir)
+	}
+	return m, nil
+}
+
+// collapseSelected closes the selected directory, or moves the selection to
+// the parent directory when there is nothing to close.
+func collapseSelected(m *Model) {
+	row, ok := m.files.current()
+	if !ok {
+		return
+	}
+	p := row.content.GetPath()
+	if row.content.GetType() == "dir" && m.files.expanded[p] {
+		m.files.collapse(p)
+		return
+	}
+	if dir := parent(p); dir != "" {
+		m.files.selectPath(dir)
+	}
+}
+
+// reloadFiles fetches the root and every expanded directory again.
+func reloadFiles(m Model) (Model, tea.Cmd) {
+	m.files.reset()
+	m.statusMsg = "Reloading repository contents..."
+	cmds := []tea.Cmd{m.loadDirectory("")}
+	for dir := range m.files.expanded {
+		cmds = append(cmds, m.loadDirectory(dir))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// syncFileList renders the file tree into the left pane and scrolls it so the
+// selected row stays visible.
+func (m *Model) syncFileList() {
+	m.leftPane.Viewport.SetContent(m.files.View())
+	height := m.leftPane.Viewport.Height
+	if height <= 0 {
+		return
+	}
+	if m.files.index < m.leftPane.Viewport.YOffset {
+		m.leftPane.Viewport.YOffset = m.files.index
+	} else if m.files.index >= m.leftPane.Viewport.YOffset+height {
+		m.leftPane.Viewport.YOffset = m.files.index - height + 1
+	}
+}
+
+// loadTree fetches the full recursive tree for the current ref using the Git
+// Trees API, so the finder can search every path without walking directories.
+func (m Model) loadTree() tea.Msg {
+	ref := m.ref
+	tree, _, err := m.gh.Git.GetTree(context.Background(), m.owner(), *m.repository.Name, ref, true)
+	if err != nil {
+		return repositoryErrorMsg(err)
+	}
+	return repositoryTreeLoadedMsg{ref: ref, entries: tree.Entries, truncated: tree.GetTruncated()}
+}
+
+func openFinder(m Model) (Model, tea.Cmd) {
+	m.finding = true
+	m.paneIndex = 1
+	m.finder = newFinderModel(m.tree)
+	return m, input.Blink
+}
+
+func updateFinder(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEscape:
+		m.finding = false
+		m.paneIndex = 0
+		m.rightPane.SetContent("")
+		return m, nil
+	case tea.KeyEnter:
+		path, ok := m.finder.selected()
+		if !ok {
+			return m, nil
 		}
-		line += " " + *content.Name
-		if i == m.fileIndex {
-			pane += common.PaneSelectedItemStyle().Render(line) + "\n"
-		} else {
-			pane += line + "\n"
+		m.finding = false
+		m.statusMsg = "Loading " + path + "..."
+		cmds := []tea.Cmd{m.loadFile(path)}
+		for _, dir := range m.files.reveal(path) {
+			cmds = append(cmds, m.loadDirectory(dir))
 		}
+		return m, tea.Batch(cmds...)
 	}
-	return pane
+	var cmd tea.Cmd
+	m.finder, cmd = m.finder.Update(msg)
+	return m, cmd
+}
+
+func (m Model) owner() string {
+	return m.repository.GetOwner().GetLogin()
 }
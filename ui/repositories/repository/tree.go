@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// treeRow is a single visible line of the file tree.
+type treeRow struct {
+	content *github.RepositoryContent
+	depth   int
+}
+
+// fileTree keeps the loaded directories of a repository together with which
+// of them are expanded. Rows are rebuilt from this state after every load so
+// the expand/collapse state and the selected path survive reloads.
+type fileTree struct {
+	directories map[string][]*github.RepositoryContent
+	expanded    map[string]bool
+	selected    string
+	index       int
+	rows        []treeRow
+}
+
+func newFileTree() fileTree {
+	return fileTree{
+		directories: map[string][]*github.RepositoryContent{},
+		expanded:    map[string]bool{},
+	}
+}
+
+// setDirectory stores the contents of dir and refreshes the visible rows.
+func (t *fileTree) setDirectory(dir string, contents []*github.RepositoryContent) {
+	t.directories[dir] = contents
+	t.refresh()
+}
+
+// loaded reports whether the contents of dir have been fetched.
+func (t fileTree) loaded(dir string) bool {
+	_, ok := t.directories[dir]
+	return ok
+}
+
+// reset drops every loaded directory but keeps the expanded paths and the
+// selection, so they can be restored once the directories are loaded again.
+func (t *fileTree) reset() {
+	t.directories = map[string][]*github.RepositoryContent{}
+	t.refresh()
+}
+
+// refresh rebuilds the visible rows and restores the selection by path.
+func (t *fileTree) refresh() {
+	t.rows = t.appendRows(nil, "", 0)
+	for i, row := range t.rows {
+		if row.content.GetPath() == t.selected {
+			t.index = i
+			return
+		}
+	}
+	if t.index >= len(t.rows) {
+		t.index = len(t.rows) - 1
+	}
+	if t.index < 0 {
+		t.index = 0
+	}
+}
+
+func (t fileTree) appendRows(rows []treeRow, dir string, depth int) []treeRow {
+	for _, content := range t.directories[dir] {
+		rows = append(rows, treeRow{content: content, depth: depth})
+		if content.GetType() == "dir" && t.expanded[content.GetPath()] {
+			rows = t.appendRows(rows, content.GetPath(), depth+1)
+		}
+	}
+	return rows
+}
+
+// current returns the selected row.
+func (t fileTree) current() (treeRow, bool) {
+	if t.index < 0 || t.index >= len(t.rows) {
+		return treeRow{}, false
+	}
+	return t.rows[t.index], true
+}
+
+func (t *fileTree) selectIndex(index int) {
+	if index < 0 || index >= len(t.rows) {
+		return
+	}
+	t.index = index
+	t.selected = t.rows[index].content.GetPath()
+}
+
+func (t *fileTree) selectPath(p string) {
+	t.selected = p
+	t.refresh()
+}
+
+func (t *fileTree) up() {
+	t.selectIndex(t.index - 1)
+}
+
+func (t *fileTree) down() {
+	t.selectIndex(t.index + 1)
+}
+
+// expand opens dir and reports whether its contents still need to be loaded.
+func (t *fileTree) expand(dir string) bool {
+	t.expanded[dir] = true
+	t.refresh()
+	return !t.loaded(dir)
+}
+
+func (t *fileTree) collapse(dir string) {
+	delete(t.expanded, dir)
+	t.refresh()
+}
+
+// reveal expands every ancestor of p and selects it. It returns the
+// ancestors whose contents still need to be loaded.
+func (t *fileTree) reveal(p string) []string {
+	var missing []string
+	if !t.loaded("") {
+		missing = append(missing, "")
+	}
+	for _, dir := range ancestors(p) {
+		t.expanded[dir] = true
+		if !t.loaded(dir) {
+			missing = append(missing, dir)
+		}
+	}
+	t.selectPath(p)
+	return missing
+}
+
+// parent returns the directory containing p, or "" at the repository root.
+func parent(p string) string {
+	dir := path.Dir(p)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// ancestors returns every directory above p, outermost first.
+func ancestors(p string) []string {
+	var dirs []string
+	for dir := parent(p); dir != ""; dir = parent(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+func (t fileTree) View() string {
+	pane := ""
+	for i, row := range t.rows {
+		content := row.content
+		line := strings.Repeat("  ", row.depth)
+		switch content.GetType() {
+		case "dir":
+			if t.expanded[content.GetPath()] {
+				line += "▾ 📁"
+			} else {
+				line += "▸ 📁"
+			}
+		case "symlink":
+			line += "  🔗"
+		case "submodule":
+			line += "  📦"
+		default:
+			line += "  📄"
+		}
+		line += " " + content.GetName()
+		switch content.GetType() {
+		case "symlink":
+			if content.GetTarget() != "" {
+				line += " → " + content.GetTarget()
+			}
+		case "submodule":
+			line += " @ " + common.ShortSHA(content.GetSHA())
+		}
+		if i == t.index {
+			pane += common.PaneSelectedItemStyle().Render(line) + "\n"
+		} else {
+			pane += line + "\n"
+		}
+	}
+	return pane
+}
+
+// breadcrumbs renders the path of the selected file or directory as a trail
+// from root, ending in its own name.
+func (t fileTree) breadcrumbs(root string) string {
+	crumbs := []string{root}
+	if row, ok := t.current(); ok {
+		crumbs = append(crumbs, strings.Split(row.content.GetPath(), "/")...)
+	}
+	return strings.Join(crumbs, " / ")
+}
+
+// refLabel shortens ref if it is a full commit SHA. Branch and tag names are
+// shown in full.
+func refLabel(ref string) string {
+	if len(ref) != 40 {
+		return ref
+	}
+	for _, c := range ref {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return ref
+		}
+	}
+	return common.ShortSHA(ref)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ghtui/ghtui/browser"
+)
+
+// currentURL returns the web URL of what is being looked at, pinned to ref:
+// the open file at the visible lines, the selected file or directory, or the
+// repository itself.
+func (m Model) currentURL(ref string) string {
+	base := m.repository.GetHTMLURL()
+	if m.paneIndex == 1 && m.selectedContents != nil {
+		u := base + "/blob/" + ref + "/" + escapePath(m.selectedContents.GetPath())
+		if m.rightPane.HasDocument() {
+			first, last := m.rightPane.Lines()
+			u += fmt.Sprintf("#L%d", first)
+			if last > first {
+				u += fmt.Sprintf("-L%d", last)
+			}
+		}
+		return u
+	}
+	if row, ok := m.files.current(); ok && m.paneIndex == 0 {
+		kind := "blob"
+		if row.content.GetType() == "dir" {
+			kind = "tree"
+		}
+		return base + "/" + kind + "/" + ref + "/" + escapePath(row.content.GetPath())
+	}
+	if ref == m.repository.GetDefaultBranch() {
+		return base
+	}
+	return base + "/tree/" + ref
+}
+
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func (m Model) openInBrowser() tea.Cmd {
+	return browser.OpenCmd(m.config, m.currentURL(m.ref))
+}
+
+// copyPermalink copies the URL of the current item pinned to the commit the
+// ref points to, so it keeps working when the branch moves on.
+func (m Model) copyPermalink() tea.Cmd {
+	return func() tea.Msg {
+		sha, _, err := m.gh.Repositories.GetCommitSHA1(context.Background(), m.owner(), *m.repository.Name, m.ref, "")
+		if err != nil {
+			return repositoryErrorMsg(err)
+		}
+		u := m.currentURL(sha)
+		if err := browser.Copy(u); err != nil {
+			return repositoryErrorMsg(err)
+		}
+		return repositoryStatusMsg("Copied " + u)
+	}
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/repositories/repository/pane"
+)
+
+func TestPermalinkOfFile(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		name   string
+		lines  int
+		offset int
+		want   string
+	}{
+		{"top", 30, 0, "#L1-L9"},
+		{"scrolled", 30, 4, "#L5-L13"},
+		{"short file", 3, 0, "#L1-L3"},
+		{"one line", 1, 0, "#L1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Model{
+				repository:       &github.Repository{HTMLURL: github.String("https://github.com/owner/repo")},
+				paneIndex:        1,
+				selectedContents: &github.RepositoryContent{Path: github.String("cmd/main file.go")},
+				rightPane:        pane.NewModel(80, 12, true),
+			}
+			text := strings.Repeat("line\n", tt.lines)
+			m.rightPane.SetDocument(text, text)
+			m.rightPane.Viewport.YOffset = tt.offset
+
+			want := "https://github.com/owner/repo/blob/" + sha + "/cmd/main%20file.go" + tt.want
+			if got := m.currentURL(sha); got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
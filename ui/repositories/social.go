@@ -0,0 +1,434 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	input "github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v39/github"
+
+	"ghtui/ghtui/ui/common"
+)
+
+// watchLevel is the notification subscription of the user to a repository.
+type watchLevel string
+
+const (
+	watchAll           watchLevel = "all"
+	watchParticipating watchLevel = "participating"
+	watchIgnore        watchLevel = "ignore"
+)
+
+var watchLevels = []watchLevel{watchAll, watchParticipating, watchIgnore}
+
+// social is whether the user starred and watches a repository.
+type social struct {
+	starred bool
+	watch   watchLevel
+}
+
+// socialUpdatedMsg reports the outcome of a star or watch change that was
+// already applied to the list. previous is restored if it failed.
+type socialUpdatedMsg struct {
+	repo     *github.Repository
+	previous social
+	done     string
+	err      error
+}
+type subscriptionLoadedMsg struct {
+	repo  *github.Repository
+	watch watchLevel
+}
+type forkOwnersLoadedMsg []string
+type forkedMsg struct {
+	source *github.Repository
+	fork   *github.Repository
+	err    error
+}
+
+// watchMenu picks the subscription level of a repository.
+type watchMenu struct {
+	open  bool
+	repo  *github.Repository
+	index int
+}
+
+// forkPrompt asks where to fork a repository to and under which name.
+type forkPrompt struct {
+	open   bool
+	repo   *github.Repository
+	owners []string
+	owner  int
+	input  input.Model
+}
+
+// socialOfLists tells which repositories the user starred and watches, keyed
+// by full name.
+func socialOfLists(starred []*github.StarredRepository, watched []*github.Repository) map[string]social {
+	socials := map[string]social{}
+	for _, s := range starred {
+		socials[s.GetRepository().GetFullName()] = social{starred: true, watch: watchParticipating}
+	}
+	for _, repo := range watched {
+		s, ok := socials[repo.GetFullName()]
+		if !ok {
+			s = social{watch: watchParticipating}
+		}
+		s.watch = watchAll
+		socials[repo.GetFullName()] = s
+	}
+	return socials
+}
+
+// loadSubscription looks up the exact subscription of repo, which the list of
+// watched repositories can't tell apart from ignoring it.
+func (m Model) loadSubscription(repo *github.Repository) tea.Cmd {
+	return func() tea.Msg {
+		subscription, resp, err := m.gh.Activity.GetRepositorySubscription(context.Background(), repo.GetOwner().GetLogin(), repo.GetName())
+		switch {
+		case err != nil && resp != nil && resp.StatusCode == http.StatusNotFound:
+			return subscriptionLoadedMsg{repo: repo, watch: watchParticipating}
+		case err != nil:
+			return nil
+		case subscription.GetIgnored():
+			return subscriptionLoadedMsg{repo: repo, watch: watchIgnore}
+		case subscription.GetSubscribed():
+			return subscriptionLoadedMsg{repo: repo, watch: watchAll}
+		}
+		return subscriptionLoadedMsg{repo: repo, watch: watchParticipating}
+	}
+}
+
+// socialOf returns what is known about the user starring and watching repo.
+func (m Model) socialOf(repo *github.Repository) social {
+	for _, listItem := range m.items {
+		if i := listItem.(item); i.repo.GetFullName() == repo.GetFullName() {
+			return i.social
+		}
+	}
+	return social{watch: watchParticipating}
+}
+
+// setSocial updates the badges and star counts of repo wherever it is shown.
+func setSocial(m Model, repo *github.Repository, s social) (Model, tea.Cmd) {
+	previous := m.socialOf(repo)
+	adjusted := map[*github.Repository]bool{}
+	adjust := func(r *github.Repository) {
+		if adjusted[r] || previous.starred == s.starred {
+			return
+		}
+		adjusted[r] = true
+		stars := r.GetStargazersCount()
+		if s.starred {
+			stars++
+		} else if stars > 0 {
+			stars--
+		}
+		r.StargazersCount = github.Int(stars)
+	}
+
+	for i, listItem := range m.items {
+		if it := listItem.(item); it.repo.GetFullName() == repo.GetFullName() {
+			adjust(it.repo)
+			it.social = s
+			m.items[i] = it
+		}
+	}
+	if m.socials != nil {
+		// The map may still be read by a list being loaded.
+		socials := make(map[string]social, len(m.socials)+1)
+		for name, known := range m.socials {
+			socials[name] = known
+		}
+		socials[repo.GetFullName()] = s
+		m.socials = socials
+	}
+	if shown := m.overview.Repository(); m.status == statusOverview && shown.GetFullName() == repo.GetFullName() {
+		adjust(shown)
+		m.overview.SetSocial(s.starred, string(s.watch))
+	}
+	if m.keys == nil {
+		return m, nil
+	}
+	return m.refreshItems()
+}
+
+// toggleStar stars or unstars repo right away and tells GitHub afterwards.
+func toggleStar(m Model, repo *github.Repository) (Model, tea.Cmd) {
+	previous := m.socialOf(repo)
+	next := previous
+	next.starred = !previous.starred
+	m, cmd := setSocial(m, repo, next)
+
+	star := func() tea.Msg {
+		ctx := context.Background()
+		owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+		var err error
+		if next.starred {
+			_, err = m.gh.Activity.Star(ctx, owner, name)
+		} else {
+			_, err = m.gh.Activity.Unstar(ctx, owner, name)
+		}
+		done := "Starred " + repo.GetFullName()
+		if !next.starred {
+			done = "Unstarred " + repo.GetFullName()
+		}
+		return socialUpdatedMsg{repo: repo, previous: previous, done: done, err: err}
+	}
+	return m, tea.Batch(cmd, star)
+}
+
+func openWatchMenu(m Model, repo *github.Repository) (Model, tea.Cmd) {
+	m.watchMenu = watchMenu{open: true, repo: repo}
+	current := m.socialOf(repo).watch
+	for i, level := range watchLevels {
+		if level == current {
+			m.watchMenu.index = i
+		}
+	}
+	return m, nil
+}
+
+func updateWatchMenu(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	menu := &m.watchMenu
+	switch msg.String() {
+	case "esc", "w":
+		menu.open = false
+	case "left", "h", "up", "k":
+		if menu.index > 0 {
+			menu.index--
+		}
+	case "right", "l", "down", "j", "tab":
+		if menu.index < len(watchLevels)-1 {
+			menu.index++
+		}
+	case "enter":
+		menu.open = false
+		return setWatch(m, menu.repo, watchLevels[menu.index])
+	}
+	return m, nil
+}
+
+// setWatch changes the subscription to repo right away and tells GitHub
+// afterwards.
+func setWatch(m Model, repo *github.Repository, level watchLevel) (Model, tea.Cmd) {
+	previous := m.socialOf(repo)
+	if previous.watch == level {
+		return m, nil
+	}
+	next := previous
+	next.watch = level
+	m, cmd := setSocial(m, repo, next)
+
+	watch := func() tea.Msg {
+		ctx := context.Background()
+		owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+		var err error
+		switch level {
+		case watchAll:
+			_, _, err = m.gh.Activity.SetRepositorySubscription(ctx, owner, name, &github.Subscription{Subscribed: github.Bool(true)})
+		case watchIgnore:
+			_, _, err = m.gh.Activity.SetRepositorySubscription(ctx, owner, name, &github.Subscription{Ignored: github.Bool(true)})
+		default:
+			// Without a subscription, only participating notifications are sent.
+			_, err = m.gh.Activity.DeleteRepositorySubscription(ctx, owner, name)
+		}
+		done := "Watching " + repo.GetFullName() + ": " + string(level)
+		return socialUpdatedMsg{repo: repo, previous: previous, done: done, err: err}
+	}
+	return m, tea.Batch(cmd, watch)
+}
+
+func (w watchMenu) View() string {
+	cells := make([]string, len(watchLevels))
+	for i, level := range watchLevels {
+		cell := " " + string(level) + " "
+		if i == w.index {
+			cell = common.PaneSelectedItemStyle().Render(cell)
+		}
+		cells[i] = cell
+	}
+	return "Watch " + w.repo.GetFullName() + ": " + strings.Join(cells, " ") +
+		lipgloss.NewStyle().Foreground(common.GrayColor()).Render("  enter: save  esc: cancel")
+}
+
+// updateSocial applies the outcome of a star or watch change, rolling the
+// badges back if GitHub refused it.
+func updateSocial(m Model, msg socialUpdatedMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m, cmd := setSocial(m, msg.repo, msg.previous)
+		return m, tea.Batch(cmd, common.Cmd(statusMsg("Could not update "+msg.repo.GetFullName()+": "+msg.err.Error())))
+	}
+	return m, common.Cmd(statusMsg(msg.done))
+}
+
+func openForkPrompt(m Model, repo *github.Repository) (Model, tea.Cmd) {
+	inputModel := input.NewModel()
+	inputModel.Prompt = "Name: "
+	inputModel.SetValue(repo.GetName())
+	inputModel.CursorEnd()
+	inputModel.Focus()
+	m.forkPrompt = forkPrompt{
+		open:   true,
+		repo:   repo,
+		owners: []string{m.user.GetLogin()},
+		input:  inputModel,
+	}
+	return m, tea.Batch(input.Blink, m.loadForkOwners)
+}
+
+// loadForkOwners lists the organizations the user can fork into.
+func (m Model) loadForkOwners() tea.Msg {
+	owners := []string{m.user.GetLogin()}
+	orgs, _, err := m.gh.Organizations.List(context.Background(), "", &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return forkOwnersLoadedMsg(owners)
+	}
+	for _, org := range orgs {
+		owners = append(owners, org.GetLogin())
+	}
+	return forkOwnersLoadedMsg(owners)
+}
+
+func updateForkPrompt(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	prompt := &m.forkPrompt
+	switch msg.Type {
+	case tea.KeyEscape:
+		prompt.open = false
+		return m, nil
+	case tea.KeyTab:
+		prompt.owner = (prompt.owner + 1) % len(prompt.owners)
+		return m, nil
+	case tea.KeyShiftTab:
+		prompt.owner = (prompt.owner + len(prompt.owners) - 1) % len(prompt.owners)
+		return m, nil
+	case tea.KeyEnter:
+		name := strings.TrimSpace(prompt.input.Value())
+		if name == "" {
+			return m, nil
+		}
+		prompt.open = false
+		return startFork(m, prompt.repo, prompt.owners[prompt.owner], name)
+	}
+	var cmd tea.Cmd
+	prompt.input, cmd = prompt.input.Update(msg)
+	return m, cmd
+}
+
+func (f forkPrompt) View() string {
+	return "Fork " + f.repo.GetFullName() + " to " +
+		common.PaneSelectedItemStyle().Render(" "+f.owners[f.owner]+" ") + "  " + f.input.View() +
+		lipgloss.NewStyle().Foreground(common.GrayColor()).Render("  tab: owner  enter: fork  esc: cancel")
+}
+
+// startFork counts the fork right away and creates it in the background.
+func startFork(m Model, repo *github.Repository, owner string, name string) (Model, tea.Cmd) {
+	repo.ForksCount = github.Int(repo.GetForksCount() + 1)
+	m, cmd := m.refreshItems()
+
+	fork := func() tea.Msg {
+		ctx := context.Background()
+		opts := &github.RepositoryCreateForkOptions{}
+		if owner != m.user.GetLogin() {
+			opts.Organization = owner
+		}
+		fork, _, err := m.gh.Repositories.CreateFork(ctx, repo.GetOwner().GetLogin(), repo.GetName(), opts)
+		var accepted *github.AcceptedError
+		if err != nil && !errors.As(err, &accepted) {
+			return forkedMsg{source: repo, err: err}
+		}
+		if name == fork.GetName() {
+			return forkedMsg{source: repo, fork: fork}
+		}
+
+		// Forks are created asynchronously, so renaming fails until it exists.
+		for attempt := 0; attempt < 5; attempt++ {
+			var renamed *github.Repository
+			renamed, _, err = m.gh.Repositories.Edit(ctx, owner, fork.GetName(), &github.Repository{Name: github.String(name)})
+			if err == nil {
+				return forkedMsg{source: repo, fork: renamed}
+			}
+			time.Sleep(2 * time.Second)
+		}
+		return forkedMsg{source: repo, fork: fork, err: errors.New("forked as " + fork.GetFullName() + " but could not rename it: " + err.Error())}
+	}
+	return m, tea.Batch(cmd, fork)
+}
+
+// updateFork adds a created fork to the list, or takes back the fork count if
+// it failed.
+func updateFork(m Model, msg forkedMsg) (Model, tea.Cmd) {
+	if msg.fork == nil {
+		if count := msg.source.GetForksCount(); count > 0 {
+			msg.source.ForksCount = github.Int(count - 1)
+		}
+		m, cmd := m.refreshItems()
+		return m, tea.Batch(cmd, common.Cmd(statusMsg("Could not fork "+msg.source.GetFullName()+": "+msg.err.Error())))
+	}
+
+	found := false
+	for _, listItem := range m.items {
+		if listItem.(item).repo.GetFullName() == msg.fork.GetFullName() {
+			found = true
+		}
+	}
+	if !found && m.source == sourceMine {
+		m.repos = append(m.repos, msg.fork)
+		m.items = append(m.items, m.newItem(msg.fork, social{watch: watchAll}))
+	}
+	m, cmd := m.refreshItems()
+	status := "Forked " + msg.source.GetFullName() + " to " + msg.fork.GetFullName()
+	if msg.err != nil {
+		status = msg.err.Error()
+	}
+	return m, tea.Batch(cmd, common.Cmd(statusMsg(status)))
+}
+
+// socialsLoadedMsg carries which repositories the user starred and watches.
+// err is set if either list could not be fetched in full.
+type socialsLoadedMsg struct {
+	socials map[string]social
+	err     error
+}
+
+// loadSocials lists the repositories the user starred and watches, for the
+// badges of every source. It runs once, after the first list was shown.
+func (m Model) loadSocials() tea.Msg {
+	starred, starredErr := m.listStarred()
+	watched, watchedErr := m.listWatched()
+	msg := socialsLoadedMsg{socials: socialOfLists(starred, watched)}
+	switch {
+	case starredErr != nil:
+		msg.err = starredErr
+	case watchedErr != nil:
+		msg.err = watchedErr
+	}
+	return msg
+}
+
+// updateSocials puts the stars and subscriptions on the listed repositories.
+func updateSocials(m Model, msg socialsLoadedMsg) (Model, tea.Cmd) {
+	m.socialsLoading = false
+	m.socials = msg.socials
+	for i, listItem := range m.items {
+		it := listItem.(item)
+		if s, ok := msg.socials[it.repo.GetFullName()]; ok {
+			it.social = s
+			m.items[i] = it
+		}
+	}
+	var status tea.Cmd
+	if msg.err != nil {
+		status = common.Cmd(statusMsg("Could not load your stars and subscriptions: " + msg.err.Error()))
+	}
+	if m.keys == nil {
+		return m, status
+	}
+	m, cmd := m.refreshItems()
+	return m, tea.Batch(cmd, status)
+}
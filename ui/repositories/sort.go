@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+type sortOrder int
+
+const (
+	sortByName sortOrder = iota
+	sortByUpdated
+	sortByStars
+	sortByCreated
+	sortByStarred
+)
+
+func (s sortOrder) String() string {
+	switch s {
+	case sortByUpdated:
+		return "last updated"
+	case sortByStars:
+		return "stars"
+	case sortByCreated:
+		return "created"
+	case sortByStarred:
+		return "star date"
+	default:
+		return "name"
+	}
+}
+
+// next returns the following sort order. Sorting by star date is only
+// offered for starred repositories.
+func (s sortOrder) next(src source) sortOrder {
+	if src == sourceStarred {
+		return (s + 1) % (sortByStarred + 1)
+	}
+	return (s + 1) % (sortByCreated + 1)
+}
+
+// sortItems orders repository items in place. Dates and stars sort newest
+// and most starred first.
+func sortItems(items []list.Item, order sortOrder) {
+	sort.SliceStable(items, func(a, b int) bool {
+		x, y := items[a].(item).repo, items[b].(item).repo
+		switch order {
+		case sortByStarred:
+			return items[a].(item).starredAt.After(items[b].(item).starredAt)
+		case sortByUpdated:
+			return x.GetUpdatedAt().After(y.GetUpdatedAt().Time)
+		case sortByStars:
+			return x.GetStargazersCount() > y.GetStargazersCount()
+		case sortByCreated:
+			return x.GetCreatedAt().After(y.GetCreatedAt().Time)
+		default:
+			return strings.ToLower(x.GetName()) < strings.ToLower(y.GetName())
+		}
+	})
+}
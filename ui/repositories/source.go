@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// source is which repositories the list shows.
+type source int
+
+const (
+	sourceMine source = iota
+	sourceStarred
+	sourceWatched
+)
+
+func (s source) String() string {
+	switch s {
+	case sourceStarred:
+		return "Starred"
+	case sourceWatched:
+		return "Watched"
+	default:
+		return "Repositories"
+	}
+}
+
+func (s source) next() source {
+	return (s + 1) % (sourceWatched + 1)
+}
+
+// sourcesView renders every source with the current one highlighted, for
+// the list title.
+func (s source) sourcesView() string {
+	view := ""
+	for i := sourceMine; i <= sourceWatched; i++ {
+		if view != "" {
+			view += " · "
+		}
+		if i == s {
+			view += "[" + i.String() + "]"
+		} else {
+			view += i.String()
+		}
+	}
+	return view
+}
+
+// listStarred fetches every repository the user starred, with the date they
+// starred it.
+func (m Model) listStarred() ([]*github.StarredRepository, error) {
+	var starred []*github.StarredRepository
+	opts := &github.ActivityListStarredOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := m.gh.Activity.ListStarred(context.Background(), "", opts)
+		if err != nil {
+			return starred, err
+		}
+		starred = append(starred, page...)
+		if resp.NextPage == 0 {
+			return starred, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listWatched fetches every repository the user watches.
+func (m Model) listWatched() ([]*github.Repository, error) {
+	var watched []*github.Repository
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := m.gh.Activity.ListWatched(context.Background(), "", opts)
+		if err != nil {
+			return watched, err
+		}
+		watched = append(watched, page...)
+		if resp.NextPage == 0 {
+			return watched, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listAffiliated fetches every repository the user owns, collaborates on or
+// can access as an organization member. Each one is tagged with the filter
+// options of the affiliations it was listed for.
+func (m Model) listAffiliated() ([]*github.Repository, map[string][]string, error) {
+	var repos []*github.Repository
+	affiliations := map[string][]string{}
+	for _, option := range affiliationOptions {
+		opts := &github.RepositoryListOptions{
+			Affiliation: affiliationQueries[option],
+			ListOptions: github.ListOptions{PerPage: 100},
+		}
+		for {
+			page, resp, err := m.gh.Repositories.List(context.Background(), "", opts)
+			if err != nil {
+				return repos, affiliations, err
+			}
+			for _, repo := range page {
+				name := repo.GetFullName()
+				if _, ok := affiliations[name]; !ok {
+					repos = append(repos, repo)
+				}
+				affiliations[name] = append(affiliations[name], option)
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	return repos, affiliations, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/google/go-github/v39/github"
 
+	"ghtui/ghtui/config"
 	"ghtui/ghtui/ui/activity"
 	"ghtui/ghtui/ui/common"
 	"ghtui/ghtui/ui/repositories"
@@ -32,29 +33,129 @@ type model struct {
 	repositories repositories.Model
 	errorMsg     string
 	user         *github.User
+	config       *config.Config
+	target       *repositories.Target
+	// session is shared by every copy of the model, so the running state can
+	// be picked up again after stopping the program for an editor.
+	session *session
+	// relay carries what commands return from one program to the next.
+	relay *relay
+}
+
+// session records why a program stopped, and in what state.
+type session struct {
+	model model
+	edit  *common.EditorMsg
+	// done is closed once the program has stopped.
+	done chan struct{}
+}
+
+// Program runs ghtui. Terminal programs can't share the terminal, so the UI is
+// stopped while an editor runs and started again with the same state once it
+// exits. What the commands still running in the meantime return is handed
+// to the next program.
+type Program struct {
+	model model
 }
 
 type userLoadedMsg *github.User
 type errorMsg error
 
-func NewProgram(username string, gh *github.Client) *tea.Program {
-	return tea.NewProgram(initialModel(username, gh), tea.WithAltScreen())
+// NewProgram starts ghtui on the repository list, or directly on target when
+// it is not nil.
+func NewProgram(username string, gh *github.Client, cfg *config.Config, target *repositories.Target) *Program {
+	return &Program{model: initialModel(username, gh, cfg, target)}
+}
+
+// Start runs the UI until the user quits.
+func (p *Program) Start() error {
+	for {
+		s := &session{done: make(chan struct{})}
+		p.model.session = s
+		err := tea.NewProgram(p.model, tea.WithAltScreen()).Start()
+		close(s.done)
+		if err != nil {
+			return err
+		}
+		if s.edit == nil {
+			return nil
+		}
+		p.model = s.model
+		p.model.relay.push(s.edit.Done(common.RunEditor(s.edit.Name, s.edit.Text)))
+	}
 }
 
-func initialModel(username string, gh *github.Client) model {
+func initialModel(username string, gh *github.Client, cfg *config.Config, target *repositories.Target) model {
 	return model{
 		username: username,
 		status:   statusInit,
 		gh:       gh,
 		spinner:  common.NewSpinnerModel(),
+		config:   cfg,
+		target:   target,
+		relay:    newRelay(),
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return spinner.Tick
+	if m.relay.pending() {
+		// Pick up what the previous program left, and the edited text.
+		return tea.Batch(m.relay.listen(m.session.done), common.Cmd(relayMsg{}))
+	}
+	return tea.Batch(m.relay.listen(m.session.done), m.relay.wrap(spinner.Tick))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case nil:
+		// Commands deliver through the relay and return nothing.
+		return m, nil
+	case relayMsg:
+		return m.updateRelayed()
+	}
+	if m.session.edit != nil {
+		// The program is stopping for the editor.
+		return m, nil
+	}
+	m, cmd := m.update(msg)
+	if m.session.edit != nil {
+		return m, tea.Quit
+	}
+	return m, m.relay.wrap(cmd)
+}
+
+// updateRelayed hands the messages waiting in the relay to the model, one at
+// a time like the program would.
+func (m model) updateRelayed() (tea.Model, tea.Cmd) {
+	cmds := []tea.Cmd{m.relay.listen(m.session.done)}
+	msgs := m.relay.take()
+	for i, msg := range msgs {
+		if m.session.edit != nil {
+			// The rest is for the program started after the editor.
+			m.relay.requeue(msgs[i:])
+			break
+		}
+		if internal(msg) {
+			msg := msg
+			cmds = append(cmds, func() tea.Msg { return msg })
+			continue
+		}
+		var cmd tea.Cmd
+		m, cmd = m.update(msg)
+		cmds = common.AppendIfNotNil(cmds, m.relay.wrap(cmd))
+	}
+	if m.session.edit != nil {
+		// The program won't run the commands once it stops, so start them
+		// here; the relay keeps what they return.
+		for _, cmd := range cmds[1:] {
+			go cmd()
+		}
+		return m, tea.Quit
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -63,6 +164,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.quit = true
 			return m, tea.Quit
 		}
+	case common.EditorMsg:
+		m.session.model = m
+		m.session.edit = &msg
+		return m, nil
 	case tea.WindowSizeMsg:
 		// TODO implement window resizing?
 	case spinner.TickMsg:
@@ -71,7 +176,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.user = msg
 		m.username = *msg.Login
 		m.status = statusReady
-		m.repositories = repositories.NewModel(msg, m.gh)
+		if m.target != nil {
+			m.repositories = repositories.NewModelWithTarget(msg, m.gh, m.config, m.target)
+		} else {
+			m.repositories = repositories.NewModel(msg, m.gh, m.config)
+		}
 		cmd = m.repositories.Init()
 	case errorMsg:
 		m.errorMsg = msg.Error()